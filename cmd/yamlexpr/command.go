@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Command is a yamlexpr subcommand.
+type Command interface {
+	// Run runs the command with arguments and returns the exit code.
+	Run(args []string) int
+	// Help returns the command usage.
+	Help() string
+}
+
+// Exit codes returned by commands.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// newFlagSet returns a flag set reporting errors to stderr, with usage printed from help.
+func newFlagSet(name string, stderr io.Writer, help func() string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, help())
+	}
+	return fs
+}
+
+// flagDefaults returns the flag defaults of a flag set, for use in help output.
+func flagDefaults(fs *flag.FlagSet) string {
+	var sb strings.Builder
+	out := fs.Output()
+	fs.SetOutput(&sb)
+	fs.PrintDefaults()
+	fs.SetOutput(out)
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// parseArgs parses flags interleaved with positional arguments,
+// so options may follow file names.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// rootFile returns the name of a file argument in the root directory.
+// Relative names are resolved relative to the root, absolute names are
+// made relative to it. Files outside the root are an error, as they can't
+// be read from the root filesystem. The stdin name is returned as is.
+func rootFile(root, file string) (string, error) {
+	if file == stdinName {
+		return file, nil
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	name := file
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(absRoot, name)
+	}
+	name, err = filepath.Rel(absRoot, name)
+	if err != nil || !filepath.IsLocal(name) {
+		return "", fmt.Errorf("file %s is outside the root directory %s, see -root", file, root)
+	}
+	return filepath.ToSlash(name), nil
+}
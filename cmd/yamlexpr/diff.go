@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+// DiffCommand compares two renderings of a template.
+type DiffCommand struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// diffOptions holds the flags of the diff command.
+type diffOptions struct {
+	processOptions
+	oldRoot     string
+	newRoot     string
+	oldVarsFile string
+	newVarsFile string
+	oldVars     varsFlag
+	newVars     varsFlag
+	key         string
+	exitCode    bool
+}
+
+// NewDiffCommand returns the diff command.
+func NewDiffCommand() *DiffCommand {
+	return &DiffCommand{
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+}
+
+// Help returns the command usage.
+func (c *DiffCommand) Help() string {
+	return `Usage: yamlexpr diff [options] file
+
+Render a template twice and report the structural differences of the
+resulting documents: the documents added or removed, and the added, removed
+and changed paths of the other documents.
+
+The renderings differ by root directory, e.g. a checkout of a shared include
+before and after a change, or by variables. -var and -vars-file apply to
+both renderings, the -old and -new variants to one of them.
+
+Documents are matched by the values at the -key paths, comma separated to
+identify matrix jobs by several values. Without -key, documents are matched
+by index.
+
+Options:
+` + flagDefaults(c.flagSet(&diffOptions{})) + `
+
+Examples:
+  yamlexpr diff -old-root main -new-root . -key name app.yaml
+  yamlexpr diff -old-var env=stage -new-var env=prod -key metadata.name app.yaml
+  yamlexpr diff -new-var replicas=3 -key os,arch -format json ci.yaml`
+}
+
+// flagSet returns the flags of the diff command, bound to opts.
+func (c *DiffCommand) flagSet(opts *diffOptions) *flag.FlagSet {
+	fs := newFlagSet("diff", c.stderr, c.Help)
+	fs.StringVar(&opts.root, "root", ".", "root `directory` for files and includes of both renderings")
+	fs.StringVar(&opts.oldRoot, "old-root", "", "root `directory` of the old rendering (default -root)")
+	fs.StringVar(&opts.newRoot, "new-root", "", "root `directory` of the new rendering (default -root)")
+	fs.StringVar(&opts.varsFile, "vars-file", "", "read variables of both renderings from a YAML or JSON `file`")
+	fs.StringVar(&opts.oldVarsFile, "old-vars-file", "", "read variables of the old rendering from a `file`")
+	fs.StringVar(&opts.newVarsFile, "new-vars-file", "", "read variables of the new rendering from a `file`")
+	fs.Var(&opts.vars, "var", "set a variable of both renderings as `name=value` (repeatable)")
+	fs.Var(&opts.oldVars, "old-var", "set a variable of the old rendering as `name=value` (repeatable)")
+	fs.Var(&opts.newVars, "new-var", "set a variable of the new rendering as `name=value` (repeatable)")
+	fs.StringVar(&opts.key, "key", "", "match documents by the values at comma separated `paths`, e.g. name or os,arch")
+	fs.StringVar(&opts.format, "format", "text", "output `format`: text or json")
+	fs.BoolVar(&opts.exitCode, "exit-code", false, "exit with 1 if the renderings differ")
+	return fs
+}
+
+// Run runs the command with arguments and returns the exit code.
+func (c *DiffCommand) Run(args []string) int {
+	var opts diffOptions
+	fs := c.flagSet(&opts)
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	diffs, err := c.diff(&opts, files[0])
+	if err != nil {
+		fmt.Fprintf(c.stderr, "error: %v\n", err)
+		return exitError
+	}
+	if err := c.write(&opts, diffs); err != nil {
+		fmt.Fprintf(c.stderr, "error: %v\n", err)
+		return exitError
+	}
+	if opts.exitCode && len(diffs) > 0 {
+		return exitError
+	}
+	return exitOK
+}
+
+// diff renders file with the old and new options and compares the documents.
+func (c *DiffCommand) diff(opts *diffOptions, file string) ([]yamlexpr.DocumentDiff, error) {
+	if opts.format != "text" && opts.format != "json" {
+		return nil, fmt.Errorf("unknown output format '%s', expected text or json", opts.format)
+	}
+
+	filename := filepath.ToSlash(filepath.Clean(file))
+	var data []byte
+	if filename == stdinName {
+		var err error
+		data, err = io.ReadAll(c.stdin)
+		if err != nil {
+			return nil, fmt.Errorf("error reading stdin: %w", err)
+		}
+	}
+
+	oldDocs, err := opts.render("old", filename, data, opts.oldRoot, opts.oldVarsFile, opts.oldVars)
+	if err != nil {
+		return nil, err
+	}
+	newDocs, err := opts.render("new", filename, data, opts.newRoot, opts.newVarsFile, opts.newVars)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	if opts.key != "" {
+		keys = strings.Split(opts.key, ",")
+	}
+	return yamlexpr.Diff(oldDocs, newDocs, keys...), nil
+}
+
+// render renders file for one side of the diff. Data holds the file read from stdin.
+// The side variables take precedence over the variables of both renderings.
+func (o *diffOptions) render(side, filename string, data []byte, root, varsFile string, vars varsFlag) ([]yamlexpr.Document, error) {
+	if root == "" {
+		root = o.root
+	}
+
+	variables, err := o.variables()
+	if err != nil {
+		return nil, err
+	}
+	sideVars, err := (&processOptions{varsFile: varsFile, vars: vars}).variables()
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range sideVars {
+		variables[k] = v
+	}
+
+	name, err := rootFile(root, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	e := yamlexpr.New(os.DirFS(root))
+	var tpl *yamlexpr.Template
+	if name == stdinName {
+		tpl, err = e.CompileYAML(stdinName, data)
+	} else {
+		tpl, err = e.LoadTemplate(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error loading %s file: %w", side, err)
+	}
+
+	docs, err := tpl.Execute(variables)
+	if err != nil {
+		return nil, fmt.Errorf("error processing %s file %s: %w", side, filename, err)
+	}
+	return docs, nil
+}
+
+// write writes the differences in the output format.
+func (c *DiffCommand) write(opts *diffOptions, diffs []yamlexpr.DocumentDiff) error {
+	if opts.format == "json" {
+		if diffs == nil {
+			diffs = []yamlexpr.DocumentDiff{}
+		}
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diffs)
+	}
+
+	for _, d := range diffs {
+		fmt.Fprintf(c.stdout, "%s document %s\n", changeSymbol(d.Kind), documentLabel(d))
+		for _, change := range d.Changes {
+			switch change.Kind {
+			case yamlexpr.ChangeAdded:
+				fmt.Fprintf(c.stdout, "    + %s: %s\n", change.Path, formatValue(change.New))
+			case yamlexpr.ChangeRemoved:
+				fmt.Fprintf(c.stdout, "    - %s: %s\n", change.Path, formatValue(change.Old))
+			default:
+				fmt.Fprintf(c.stdout, "    ~ %s: %s -> %s\n", change.Path, formatValue(change.Old), formatValue(change.New))
+			}
+		}
+	}
+	return nil
+}
+
+// changeSymbol returns the symbol of a change kind in text output.
+func changeSymbol(kind yamlexpr.ChangeKind) string {
+	switch kind {
+	case yamlexpr.ChangeAdded:
+		return "+"
+	case yamlexpr.ChangeRemoved:
+		return "-"
+	}
+	return "~"
+}
+
+// documentLabel identifies a document in text output, by key or by index.
+func documentLabel(d yamlexpr.DocumentDiff) string {
+	if d.Key != "" {
+		return d.Key
+	}
+	if d.NewIndex >= 0 {
+		return fmt.Sprint(d.NewIndex)
+	}
+	return fmt.Sprint(d.OldIndex)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffCommand(t *testing.T) {
+	oldRoot := writeFiles(t, map[string]string{
+		"app.yaml":   "services:\n  - for: svc in [\"api\", \"worker\"]\n    name: ${svc}\n    include: _base.yaml\n",
+		"jobs.yaml":  "for: svc in services\nname: ${svc}\nreplicas: ${replicas}\n",
+		"_base.yaml": "replicas: 2\nlabels:\n  team: core\n",
+	})
+	newRoot := writeFiles(t, map[string]string{
+		"app.yaml":   "services:\n  - for: svc in [\"api\", \"worker\"]\n    name: ${svc}\n    include: _base.yaml\n",
+		"_base.yaml": "replicas: 3\nlabels:\n  team: core\n",
+	})
+	varsFile := filepath.Join(t.TempDir(), "vars.yaml")
+	require.NoError(t, os.WriteFile(varsFile, []byte("services: [api, worker]\nreplicas: 1\n"), 0o644))
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		want   string
+		code   int
+		stderr string
+	}{
+		{
+			name: "roots",
+			args: []string{"-old-root", oldRoot, "-new-root", newRoot, "app.yaml"},
+			want: "~ document 0\n" +
+				"    ~ services[0].replicas: 2 -> 3\n" +
+				"    ~ services[1].replicas: 2 -> 3\n",
+		},
+		{
+			name: "variables matched by key",
+			args: []string{"-root", oldRoot, "-vars-file", varsFile, "-new-var", "services=[api, cache]", "-new-var", "replicas=2", "-key", "name", "jobs.yaml"},
+			want: "~ document name=api\n" +
+				"    ~ replicas: 1 -> 2\n" +
+				"- document name=worker\n" +
+				"+ document name=cache\n",
+		},
+		{
+			name:  "json",
+			args:  []string{"-old-var", "port=80", "-new-var", "port=8080", "-format", "json", "-"},
+			stdin: "port: ${port}\n",
+			want: `[
+  {
+    "kind": "changed",
+    "old_index": 0,
+    "new_index": 0,
+    "changes": [
+      {
+        "kind": "changed",
+        "path": "port",
+        "old": 80,
+        "new": 8080
+      }
+    ]
+  }
+]
+`,
+		},
+		{
+			name: "no differences",
+			args: []string{"-root", oldRoot, "-exit-code", "app.yaml"},
+			want: "",
+		},
+		{
+			name: "exit code",
+			args: []string{"-old-root", oldRoot, "-new-root", newRoot, "-exit-code", "app.yaml"},
+			code: exitError,
+			want: "~ document 0\n" +
+				"    ~ services[0].replicas: 2 -> 3\n" +
+				"    ~ services[1].replicas: 2 -> 3\n",
+		},
+		{
+			name:   "new rendering fails",
+			args:   []string{"-old-root", oldRoot, "-new-root", newRoot, "-vars-file", varsFile, "jobs.yaml"},
+			code:   exitError,
+			stderr: "error loading new file: error reading file jobs.yaml",
+		},
+		{
+			name:   "missing file argument",
+			args:   []string{"-root", oldRoot},
+			code:   exitUsage,
+			stderr: "Usage: yamlexpr diff",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			cmd := &DiffCommand{
+				stdin:  strings.NewReader(tc.stdin),
+				stdout: stdout,
+				stderr: stderr,
+			}
+
+			code := cmd.Run(tc.args)
+			require.Equal(t, tc.code, code, stderr.String())
+			require.Contains(t, stderr.String(), tc.stderr)
+			if tc.stderr == "" {
+				require.Equal(t, tc.want, stdout.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+// ExplainCommand explains how the values of processed documents were produced.
+type ExplainCommand struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// NewExplainCommand returns the explain command.
+func NewExplainCommand() *ExplainCommand {
+	return &ExplainCommand{
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+}
+
+// Help returns the command usage.
+func (c *ExplainCommand) Help() string {
+	return `Usage: yamlexpr explain [options] file [path]
+
+Process a YAML file and explain how each output value was produced: the
+template location, the include it came from, the if conditions and the for
+or matrix iterations producing it, and the interpolation with the variable
+values used. Blocks omitted by false conditions are listed with the
+condition. With a path, only values at or below the path are explained.
+Secrets are read as in the process command, and their values are redacted.
+With the file "-", the document is read from stdin.
+
+Options:
+` + flagDefaults(c.flagSet(&processOptions{})) + `
+
+Examples:
+  yamlexpr explain app.yaml
+  yamlexpr explain -var env=prod app.yaml services[1]
+  yamlexpr explain -format json app.yaml
+  yamlexpr explain -secrets-dir /run/secrets app.yaml`
+}
+
+// flagSet returns the flags of the explain command, bound to opts.
+func (c *ExplainCommand) flagSet(opts *processOptions) *flag.FlagSet {
+	fs := newFlagSet("explain", c.stderr, c.Help)
+	fs.StringVar(&opts.root, "root", ".", "root `directory` for files and includes")
+	fs.StringVar(&opts.format, "format", "text", "output `format`: text or json")
+	fs.StringVar(&opts.varsFile, "vars-file", "", "read variables from a YAML or JSON `file`")
+	fs.Var(&opts.vars, "var", "set a variable as `name=value`, the value is parsed as YAML (repeatable)")
+	secretFlags(fs, opts)
+	return fs
+}
+
+// Run runs the command with arguments and returns the exit code.
+func (c *ExplainCommand) Run(args []string) int {
+	var opts processOptions
+	fs := c.flagSet(&opts)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if len(positional) < 1 || len(positional) > 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	file, path := positional[0], ""
+	if len(positional) == 2 {
+		path = positional[1]
+	}
+
+	if err := c.explain(&opts, file, path); err != nil {
+		fmt.Fprintf(c.stderr, "error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// explain processes file and writes the explanation of values at path.
+func (c *ExplainCommand) explain(opts *processOptions, file, path string) error {
+	if opts.format != "text" && opts.format != "json" {
+		return fmt.Errorf("unknown output format '%s', expected text or json", opts.format)
+	}
+
+	vars, err := opts.variables()
+	if err != nil {
+		return err
+	}
+
+	filename, err := rootFile(opts.root, file)
+	if err != nil {
+		return err
+	}
+	e := yamlexpr.New(os.DirFS(opts.root), opts.secretOptions()...)
+	tpl, err := loadTemplate(e, c.stdin, filename)
+	if err != nil {
+		return err
+	}
+	x, err := tpl.Explain(vars)
+	if err != nil {
+		return fmt.Errorf("error processing file %s: %w", filename, err)
+	}
+
+	if path != "" {
+		x = filterExplanation(x, path)
+		if len(x.Values) == 0 && len(x.Omitted) == 0 {
+			return fmt.Errorf("no values at %s", path)
+		}
+	}
+
+	if opts.format == "json" {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(x)
+	}
+	writeExplanation(c.stdout, x)
+	return nil
+}
+
+// filterExplanation returns the values and omitted blocks at or below path.
+func filterExplanation(x *yamlexpr.Explanation, path string) *yamlexpr.Explanation {
+	result := &yamlexpr.Explanation{
+		Documents: x.Documents,
+	}
+	for _, v := range x.Values {
+		if pathHasPrefix(v.Path, path) {
+			result.Values = append(result.Values, v)
+		}
+	}
+	for _, o := range x.Omitted {
+		if pathHasPrefix(o.Path, path) {
+			result.Omitted = append(result.Omitted, o)
+		}
+	}
+	return result
+}
+
+// pathHasPrefix reports whether path is prefix, or a path below prefix.
+func pathHasPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	rest := path[len(prefix):]
+	return rest == "" || rest[0] == '.' || rest[0] == '['
+}
+
+// writeExplanation writes the explanation as text.
+func writeExplanation(w io.Writer, x *yamlexpr.Explanation) {
+	multi := len(x.Documents) > 1
+	for i, v := range x.Values {
+		if multi && (i == 0 || x.Values[i-1].Document != v.Document) {
+			fmt.Fprintf(w, "# document %d\n", v.Document)
+		}
+		fmt.Fprintf(w, "%s = %s\n", v.Path, formatValue(v.Value))
+		writeDetails(w, v.Source, v.Steps)
+		if v.Interpolation != nil {
+			fmt.Fprintf(w, "    interpolation: %s%s\n", v.Interpolation.Expression, formatVars(v.Interpolation.Vars))
+		}
+	}
+
+	if len(x.Omitted) > 0 {
+		fmt.Fprintln(w, "# omitted")
+	}
+	for _, o := range x.Omitted {
+		fmt.Fprintf(w, "%s\n", o.Path)
+		writeDetails(w, o.Source, o.Steps)
+	}
+}
+
+// writeDetails writes the source location and processing steps of a value.
+func writeDetails(w io.Writer, source string, steps []yamlexpr.TraceEvent) {
+	if source != "" {
+		fmt.Fprintf(w, "    source: %s\n", source)
+	}
+	for _, step := range steps {
+		switch step.Kind {
+		case yamlexpr.TraceInclude:
+			fmt.Fprintf(w, "    include: %s\n", step.File)
+		case yamlexpr.TraceCondition:
+			fmt.Fprintf(w, "    if: %s -> %v%s\n", step.Expression, step.Result, formatVars(step.Vars))
+		case yamlexpr.TraceFor:
+			fmt.Fprintf(w, "    for: %s, iteration %d%s\n", step.Expression, step.Index, formatVars(step.Vars))
+		case yamlexpr.TraceMatrix:
+			fmt.Fprintf(w, "    matrix: job %d%s\n", step.Index, formatVars(step.Vars))
+		}
+	}
+}
+
+// formatVars formats variables as ` (name=value, ...)`, sorted by name.
+func formatVars(vars map[string]any) string {
+	if len(vars) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + formatValue(vars[name])
+	}
+	return " (" + strings.Join(pairs, ", ") + ")"
+}
+
+// formatValue formats a value as JSON, or with %v if it can't be encoded.
+func formatValue(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app.yaml": `env: dev
+services:
+  - for: svc in ["api", "cache"]
+    if: svc != "cache"
+    name: ${svc}-${env}
+    include: _labels.yaml
+debug:
+  if: env == "prod"
+  level: 1
+`,
+		"_labels.yaml": "team: core\n",
+	})
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		want   string
+		code   int
+		stderr string
+	}{
+		{
+			name: "all values",
+			args: []string{"-root", dir, "app.yaml"},
+			want: `env = "dev"
+    source: app.yaml:1
+services[0].name = "api-dev"
+    source: app.yaml:5
+    for: svc in ["api", "cache"], iteration 0 (svc="api")
+    if: svc != "cache" -> true (svc="api")
+    interpolation: ${svc}-${env} (env="dev", svc="api")
+services[0].team = "core"
+    source: _labels.yaml:1
+    for: svc in ["api", "cache"], iteration 0 (svc="api")
+    include: _labels.yaml
+# omitted
+debug
+    source: app.yaml:7
+    if: env == "prod" -> false (env="dev")
+services[0][1]
+    source: app.yaml:3
+    for: svc in ["api", "cache"], iteration 1 (svc="cache")
+    if: svc != "cache" -> false (svc="cache")
+`,
+		},
+		{
+			name: "path",
+			args: []string{"-root", dir, "-var", "env=prod", "app.yaml", "debug"},
+			want: `debug.level = 1
+    source: app.yaml:9
+    if: env == "prod" -> true (env="prod")
+`,
+		},
+		{
+			name:  "json",
+			args:  []string{"-format", "json", "-", "port"},
+			stdin: "port: 8080\n",
+			want: `{
+  "values": [
+    {
+      "document": 0,
+      "path": "port",
+      "value": 8080,
+      "source": "-:1"
+    }
+  ]
+}
+`,
+		},
+		{
+			name:   "no values at path",
+			args:   []string{"-root", dir, "app.yaml", "services[1]"},
+			code:   exitError,
+			stderr: "no values at services[1]",
+		},
+		{
+			name:   "missing file",
+			args:   []string{},
+			code:   exitUsage,
+			stderr: "Usage: yamlexpr explain",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			cmd := &ExplainCommand{
+				stdin:  strings.NewReader(tc.stdin),
+				stdout: stdout,
+				stderr: stderr,
+			}
+
+			code := cmd.Run(tc.args)
+			require.Equal(t, tc.code, code, stderr.String())
+			require.Contains(t, stderr.String(), tc.stderr)
+			if tc.code == exitOK {
+				require.Equal(t, tc.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestExplainCommand_Secrets(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app.yaml": "dsn: postgres://app:${secret(\"password\")}@db/${secret(\"name\")}\n",
+	})
+	secrets := writeFiles(t, map[string]string{
+		"password": "hunter2\n",
+	})
+	t.Setenv("TEST_SECRET_NAME", "appdb")
+	flags := []string{"-root", dir, "-secrets-dir", secrets, "-secrets-env-prefix", "TEST_SECRET_"}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	explain := &ExplainCommand{stdout: stdout, stderr: stderr}
+	require.Equal(t, exitOK, explain.Run(append(flags, "app.yaml")), stderr.String())
+	require.Contains(t, stdout.String(), `dsn = "postgres://app:***@db/***"`)
+	require.NotContains(t, stdout.String(), "hunter2")
+
+	process, stdout, stderr := newTestProcessCommand("")
+	require.Equal(t, exitOK, process.Run(append(flags, "app.yaml")), stderr.String())
+	require.Equal(t, "dsn: postgres://app:hunter2@db/appdb\n", stdout.String())
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/titpetric/yamlexpr/fixture"
+)
+
+// readmeName is the file holding the introduction of a feature.
+const readmeName = "README.md"
+
+// GenCommand generates feature documentation from fixtures.
+type GenCommand struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// genOptions holds the flags of the gen command.
+type genOptions struct {
+	dir     string
+	feature string
+	output  string
+}
+
+// NewGenCommand returns the gen command.
+func NewGenCommand() *GenCommand {
+	return &GenCommand{
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+}
+
+// Help returns the command usage.
+func (c *GenCommand) Help() string {
+	return `Usage: yamlexpr gen [options]
+
+Generate markdown documentation for features from fixtures. A feature is a
+directory of fixtures. The README.md in the directory is the introduction,
+followed by an example for each fixture, using the frontmatter title,
+description, category and tags. Each input is processed, so the outputs
+shown are the actual results. A fixture that fails to process is an error,
+a fixture with output differing from the expected output is reported.
+
+Options:
+` + flagDefaults(c.flagSet(&genOptions{})) + `
+
+Examples:
+  yamlexpr gen -feature for-loops
+  yamlexpr gen -feature matrix -o docs/features
+  yamlexpr gen -o docs/features`
+}
+
+// flagSet returns the flags of the gen command, bound to opts.
+func (c *GenCommand) flagSet(opts *genOptions) *flag.FlagSet {
+	fs := newFlagSet("gen", c.stderr, c.Help)
+	fs.StringVar(&opts.dir, "dir", "testdata/fixtures-by-feature", "`directory` with a fixture directory for each feature")
+	fs.StringVar(&opts.feature, "feature", "", "generate documentation for the feature `name` (default all features)")
+	fs.StringVar(&opts.output, "o", "", "write <feature>.md files to `directory` (default stdout)")
+	return fs
+}
+
+// Run runs the command with arguments and returns the exit code.
+func (c *GenCommand) Run(args []string) int {
+	var opts genOptions
+	fs := c.flagSet(&opts)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(c.stderr, "error: unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		return exitUsage
+	}
+	if opts.feature == "" && opts.output == "" {
+		fmt.Fprintln(c.stderr, "error: -feature or -o is required")
+		return exitUsage
+	}
+
+	if err := c.generate(&opts); err != nil {
+		fmt.Fprintf(c.stderr, "error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// generate renders the selected features, to stdout or to the output directory.
+func (c *GenCommand) generate(opts *genOptions) error {
+	features := []string{opts.feature}
+	if opts.feature == "" {
+		var err error
+		if features, err = listFeatures(opts.dir); err != nil {
+			return err
+		}
+	}
+
+	for _, feature := range features {
+		out, err := c.render(filepath.Join(opts.dir, feature))
+		if err != nil {
+			return err
+		}
+
+		if opts.output == "" {
+			if _, err := c.stdout.Write(out); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(opts.output, feature+".md"), out, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// render renders the documentation of the feature in dir.
+func (c *GenCommand) render(dir string) ([]byte, error) {
+	fixtures, err := fixture.LoadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	readme, err := os.ReadFile(filepath.Join(dir, readmeName))
+	switch {
+	case err == nil:
+		buf.WriteString(strings.TrimSpace(string(readme)) + "\n")
+	case errors.Is(err, os.ErrNotExist):
+		fmt.Fprintf(&buf, "# %s\n", featureTitle(filepath.Base(dir)))
+	default:
+		return nil, err
+	}
+
+	includes, err := loadIncludes(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fixtures) > 0 {
+		buf.WriteString("\n## Examples\n")
+	}
+
+	for _, f := range fixtures {
+		r := f.Run()
+		if r.Err != nil {
+			return nil, fmt.Errorf("error running fixture %s: %w", f.Path, r.Err)
+		}
+		if r.Diff != "" {
+			fmt.Fprintf(c.stderr, "warning: fixture %s output differs from expected output\n", f.Path)
+		}
+		writeExample(&buf, f, includes, r.Actual)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeExample writes the example section of a fixture with the actual output documents.
+// Include files referenced by the input are shown after the input.
+func writeExample(buf *bytes.Buffer, f *fixture.Fixture, includes []include, actual []string) {
+	title := f.Title
+	if title == "" {
+		title = f.Name
+	}
+	fmt.Fprintf(buf, "\n### %s\n", title)
+
+	if f.Description != "" {
+		fmt.Fprintf(buf, "\n%s\n", f.Description)
+	}
+
+	var meta []string
+	if f.Category != "" {
+		meta = append(meta, "**Category:** "+f.Category)
+	}
+	if len(f.Tags) > 0 {
+		tags := make([]string, len(f.Tags))
+		for i, tag := range f.Tags {
+			tags[i] = "`" + tag + "`"
+		}
+		meta = append(meta, "**Tags:** "+strings.Join(tags, ", "))
+	}
+	if len(meta) > 0 {
+		fmt.Fprintf(buf, "\n%s\n", strings.Join(meta, " | "))
+	}
+
+	fmt.Fprintf(buf, "\n**Input:**\n\n```yaml\n%s\n```\n", strings.TrimSpace(f.Input))
+
+	for _, inc := range includes {
+		if strings.Contains(f.Input, inc.name) {
+			fmt.Fprintf(buf, "\n**`%s`:**\n\n```yaml\n%s\n```\n", inc.name, inc.source)
+		}
+	}
+
+	label := "**Output:**"
+	if len(actual) != 1 {
+		label = fmt.Sprintf("**Output (%d documents):**", len(actual))
+	}
+	fmt.Fprintf(buf, "\n%s\n\n```yaml\n%s\n```\n", label, strings.Join(actual, "\n---\n"))
+}
+
+// include is a file included by fixtures of a feature.
+type include struct {
+	name   string
+	source string
+}
+
+// loadIncludes loads the include files in dir, named with a leading _.
+func loadIncludes(dir string) ([]include, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "_*.y*ml"))
+	if err != nil {
+		return nil, err
+	}
+
+	includes := make([]include, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		includes = append(includes, include{
+			name:   filepath.Base(path),
+			source: strings.TrimSpace(string(data)),
+		})
+	}
+	return includes, nil
+}
+
+// listFeatures returns the names of the feature directories in dir.
+func listFeatures(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var features []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			features = append(features, entry.Name())
+		}
+	}
+	return features, nil
+}
+
+// featureTitle returns a title for a feature name, e.g. "For loops" for "for-loops".
+func featureTitle(name string) string {
+	title := strings.ReplaceAll(name, "-", " ")
+	if title == "" {
+		return title
+	}
+	return strings.ToUpper(title[:1]) + title[1:]
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenCommand(t *testing.T) {
+	dir := t.TempDir()
+	feature := filepath.Join(dir, "loops")
+	require.NoError(t, os.Mkdir(feature, 0o755))
+	writeFixture := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(feature, name), []byte(content), 0o644))
+	}
+
+	writeFixture("README.md", "# Loops\n\nIntroduction.\n")
+	writeFixture("_labels.yaml", "team: core\n")
+	writeFixture("001-loop.yaml", `---
+title: "Simple Loop"
+description: "Iterate over a list."
+category: "basics"
+tags: ["for"]
+---
+items:
+  - for: v in ["a", "b"]
+    name: "${v}"
+labels:
+  include: _labels.yaml
+---
+items:
+  - name: a
+  - name: b
+labels:
+  team: core
+`)
+
+	want := "# Loops\n\nIntroduction.\n" +
+		"\n## Examples\n" +
+		"\n### Simple Loop\n" +
+		"\nIterate over a list.\n" +
+		"\n**Category:** basics | **Tags:** `for`\n" +
+		"\n**Input:**\n\n```yaml\nitems:\n  - for: v in [\"a\", \"b\"]\n    name: \"${v}\"\nlabels:\n  include: _labels.yaml\n```\n" +
+		"\n**`_labels.yaml`:**\n\n```yaml\nteam: core\n```\n" +
+		"\n**Output:**\n\n```yaml\nitems:\n  - name: a\n  - name: b\nlabels:\n  team: core\n```\n"
+
+	t.Run("stdout", func(t *testing.T) {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		cmd := &GenCommand{stdout: stdout, stderr: stderr}
+
+		require.Equal(t, exitOK, cmd.Run([]string{"-dir", dir, "-feature", "loops"}), stderr.String())
+		require.Equal(t, want, stdout.String())
+		require.Empty(t, stderr.String())
+	})
+
+	t.Run("output directory", func(t *testing.T) {
+		output := t.TempDir()
+		cmd := &GenCommand{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}
+
+		require.Equal(t, exitOK, cmd.Run([]string{"-dir", dir, "-o", output}))
+		data, err := os.ReadFile(filepath.Join(output, "loops.md"))
+		require.NoError(t, err)
+		require.Equal(t, want, string(data))
+	})
+
+	t.Run("shows actual output", func(t *testing.T) {
+		writeFixture("002-stale.yaml", "---\ntitle: Stale\n---\nname: ${\"x\" + \"y\"}\n---\nname: stale\n")
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		cmd := &GenCommand{stdout: stdout, stderr: stderr}
+
+		require.Equal(t, exitOK, cmd.Run([]string{"-dir", dir, "-feature", "loops"}))
+		require.Contains(t, stdout.String(), "**Output:**\n\n```yaml\nname: xy\n```\n")
+		require.Contains(t, stderr.String(), "warning: fixture "+filepath.Join(feature, "002-stale.yaml")+" output differs")
+	})
+
+	t.Run("processing error", func(t *testing.T) {
+		writeFixture("003-error.yaml", "---\ntitle: Error\n---\nitems:\n  - for: v in missing\n---\n")
+		stderr := &bytes.Buffer{}
+		cmd := &GenCommand{stdout: &bytes.Buffer{}, stderr: stderr}
+
+		require.Equal(t, exitError, cmd.Run([]string{"-dir", dir, "-feature", "loops"}))
+		require.Contains(t, stderr.String(), "error running fixture")
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		stderr := &bytes.Buffer{}
+		cmd := &GenCommand{stdout: &bytes.Buffer{}, stderr: stderr}
+
+		require.Equal(t, exitUsage, cmd.Run(nil))
+		require.Contains(t, stderr.String(), "-feature or -o is required")
+	})
+}
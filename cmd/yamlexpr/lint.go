@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+// LintCommand checks templates without rendering them.
+type LintCommand struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// lintOptions holds the flags of the lint command.
+type lintOptions struct {
+	processOptions
+	strict bool
+}
+
+// NewLintCommand returns the lint command.
+func NewLintCommand() *LintCommand {
+	return &LintCommand{
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+}
+
+// Help returns the command usage.
+func (c *LintCommand) Help() string {
+	return `Usage: yamlexpr lint [options] [file ...]
+
+Check templates without rendering them, following their includes. Reported
+problems are malformed for expressions, expressions failing to compile,
+variables not defined in any scope, misspelled directives, missing includes,
+unused inputs and matrices producing no jobs. Variables passed with -var and
+-vars-file are defined, as when processing.
+
+With -format json the diagnostics are written as a JSON array, and with
+-format sarif as a SARIF 2.1.0 log for code scanning annotations in CI.
+The exit code is 1 if any problem is found.
+
+Options:
+` + flagDefaults(c.flagSet(&lintOptions{})) + `
+
+Examples:
+  yamlexpr lint app.yaml
+  yamlexpr lint -root deploy -var env=prod app.yaml worker.yaml
+  yamlexpr lint -format sarif app.yaml > lint.sarif`
+}
+
+// flagSet returns the flags of the lint command, bound to opts.
+func (c *LintCommand) flagSet(opts *lintOptions) *flag.FlagSet {
+	fs := newFlagSet("lint", c.stderr, c.Help)
+	fs.StringVar(&opts.root, "root", ".", "root `directory` for files and includes")
+	fs.StringVar(&opts.format, "format", "text", "output `format`: text, json or sarif")
+	fs.StringVar(&opts.varsFile, "vars-file", "", "read variables from a YAML or JSON `file`")
+	fs.Var(&opts.vars, "var", "set a variable as `name=value` (repeatable)")
+	fs.BoolVar(&opts.strict, "strict", false, "report misspelled directives as errors and check conditions strictly")
+	return fs
+}
+
+// Run runs the command with arguments and returns the exit code.
+func (c *LintCommand) Run(args []string) int {
+	var opts lintOptions
+	files, err := parseArgs(c.flagSet(&opts), args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	diagnostics, err := c.lint(&opts, files)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "error: %v\n", err)
+		return exitError
+	}
+	if err := c.write(&opts, diagnostics); err != nil {
+		fmt.Fprintf(c.stderr, "error: %v\n", err)
+		return exitError
+	}
+	if len(diagnostics) > 0 {
+		return exitError
+	}
+	return exitOK
+}
+
+// lint checks files and returns their diagnostics.
+func (c *LintCommand) lint(opts *lintOptions, files []string) ([]yamlexpr.Diagnostic, error) {
+	switch opts.format {
+	case "text", "json", "sarif":
+	default:
+		return nil, fmt.Errorf("unknown output format '%s', expected text, json or sarif", opts.format)
+	}
+
+	vars, err := opts.variables()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		files = []string{stdinName}
+	}
+
+	var configOpts []yamlexpr.ConfigOption
+	if opts.strict {
+		configOpts = append(configOpts, yamlexpr.WithStrict())
+	}
+	e := yamlexpr.New(os.DirFS(opts.root), configOpts...)
+
+	var diagnostics []yamlexpr.Diagnostic
+	for _, file := range files {
+		filename, err := rootFile(opts.root, file)
+		if err != nil {
+			return nil, err
+		}
+
+		var result []yamlexpr.Diagnostic
+		if filename == stdinName {
+			data, err := io.ReadAll(c.stdin)
+			if err != nil {
+				return nil, fmt.Errorf("error reading stdin: %w", err)
+			}
+			result, err = e.LintYAML(stdinName, data, vars)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			result, err = e.Lint(filename, vars)
+			if err != nil {
+				return nil, err
+			}
+		}
+		diagnostics = append(diagnostics, result...)
+	}
+	return diagnostics, nil
+}
+
+// write writes the diagnostics in the output format.
+func (c *LintCommand) write(opts *lintOptions, diagnostics []yamlexpr.Diagnostic) error {
+	switch opts.format {
+	case "json":
+		if diagnostics == nil {
+			diagnostics = []yamlexpr.Diagnostic{}
+		}
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diagnostics)
+	case "sarif":
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(sarifLog(diagnostics, filepath.ToSlash(opts.root)))
+	}
+
+	for _, d := range diagnostics {
+		fmt.Fprintln(c.stdout, d)
+	}
+	return nil
+}
+
+// sarif is a SARIF 2.1.0 log, with the properties used by lint.
+type sarif struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+// sarifLog returns the SARIF log of diagnostics. File locations are
+// relative to root, so they resolve from the working directory.
+func sarifLog(diagnostics []yamlexpr.Diagnostic, root string) sarif {
+	rules := make([]string, 0, len(yamlexpr.LintRules))
+	for id := range yamlexpr.LintRules {
+		rules = append(rules, id)
+	}
+	sort.Strings(rules)
+
+	driver := sarifDriver{
+		Name:           "yamlexpr",
+		InformationURI: "https://github.com/titpetric/yamlexpr",
+	}
+	for _, id := range rules {
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:               id,
+			ShortDescription: sarifMessage{Text: yamlexpr.LintRules[id]},
+		})
+	}
+
+	results := make([]sarifResult, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		result := sarifResult{
+			RuleID:  d.Rule,
+			Level:   string(d.Severity),
+			Message: sarifMessage{Text: d.Message},
+		}
+		if d.File != "" && d.File != stdinName {
+			result.Locations = []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: path.Join(root, d.File)},
+					Region:           sarifRegion{StartLine: d.Line, StartColumn: d.Column},
+				},
+			}}
+		}
+		results = append(results, result)
+	}
+
+	return sarif{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: driver},
+			Results: results,
+		}},
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app.yaml":   "name: ${nmae}\ninclude: _base.yaml\n",
+		"clean.yaml": "name: app\nimage: ${name}:latest\n",
+	})
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		want   string
+		code   int
+		stderr string
+	}{
+		{
+			name: "clean",
+			args: []string{"-root", dir, "clean.yaml"},
+			want: "",
+		},
+		{
+			name: "text",
+			args: []string{"-root", dir, "app.yaml", "clean.yaml"},
+			code: exitError,
+			want: "app.yaml:1:7: warning: undefined variable 'nmae' at name (undefined-variable)\n" +
+				"app.yaml:2:10: error: included file _base.yaml does not exist at include (missing-include)\n",
+		},
+		{
+			name:  "var defines a variable",
+			args:  []string{"-var", "nmae=app", "-"},
+			stdin: "name: ${nmae}\n",
+		},
+		{
+			name:  "json",
+			args:  []string{"-format", "json", "-"},
+			stdin: "items:\n  - for: item of items\n",
+			code:  exitError,
+			want: `[
+  {
+    "rule": "invalid-for",
+    "severity": "error",
+    "message": "invalid for expression 'item of items' at items[0].for: invalid for expression syntax: \"item of items\" (expected 'var in source' or '(var1, var2) in source', source can be a path like 'item.subitem' or an expression)",
+    "file": "-",
+    "line": 2,
+    "column": 10,
+    "path": "items[0].for"
+  }
+]
+`,
+		},
+		{
+			name:   "unknown format",
+			args:   []string{"-format", "xml", "app.yaml"},
+			code:   exitError,
+			stderr: "unknown output format 'xml'",
+		},
+		{
+			name:   "missing file",
+			args:   []string{"-root", dir, "missing.yaml"},
+			code:   exitError,
+			stderr: "error reading file missing.yaml",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			cmd := &LintCommand{
+				stdin:  strings.NewReader(tc.stdin),
+				stdout: stdout,
+				stderr: stderr,
+			}
+
+			code := cmd.Run(tc.args)
+			require.Equal(t, tc.code, code, stderr.String())
+			require.Contains(t, stderr.String(), tc.stderr)
+			if tc.stderr == "" {
+				require.Equal(t, tc.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestLintCommand_SARIF(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app.yaml": "name: ${nmae}\n",
+	})
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := &LintCommand{stdout: stdout, stderr: stderr}
+
+	require.Equal(t, exitError, cmd.Run([]string{"-root", dir, "-format", "sarif", "app.yaml"}), stderr.String())
+
+	var log sarif
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &log))
+	require.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	require.Equal(t, "yamlexpr", log.Runs[0].Tool.Driver.Name)
+	require.NotEmpty(t, log.Runs[0].Tool.Driver.Rules)
+
+	require.Equal(t, []sarifResult{{
+		RuleID:  "undefined-variable",
+		Level:   "warning",
+		Message: sarifMessage{Text: "undefined variable 'nmae' at name"},
+		Locations: []sarifLocation{{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: dir + "/app.yaml"},
+				Region:           sarifRegion{StartLine: 1, StartColumn: 7},
+			},
+		}},
+	}}, log.Runs[0].Results)
+}
@@ -6,17 +6,20 @@ import (
 )
 
 func main() {
-	if err := start(os.Args[1:]); err != nil {
+	code, err := start(os.Args[1:])
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitError)
 	}
+	os.Exit(code)
 }
 
 // start handles the main command dispatch and execution.
-func start(args []string) error {
+// It returns the exit code of the command. Commands print their own errors.
+func start(args []string) (int, error) {
 	if len(args) < 1 {
 		printUsage()
-		return nil
+		return exitOK, nil
 	}
 
 	// Parse command from first argument
@@ -34,6 +37,14 @@ func start(args []string) error {
 		command = NewTestCommand()
 	case "gen":
 		command = NewGenCommand()
+	case "explain":
+		command = NewExplainCommand()
+	case "lint":
+		command = NewLintCommand()
+	case "diff":
+		command = NewDiffCommand()
+	case "repl":
+		command = NewReplCommand()
 	case "help":
 		if len(cmdArgs) > 0 {
 			// Help for specific command
@@ -45,23 +56,27 @@ func start(args []string) error {
 				fmt.Println(NewTestCommand().Help())
 			case "gen":
 				fmt.Println(NewGenCommand().Help())
+			case "explain":
+				fmt.Println(NewExplainCommand().Help())
+			case "lint":
+				fmt.Println(NewLintCommand().Help())
+			case "diff":
+				fmt.Println(NewDiffCommand().Help())
+			case "repl":
+				fmt.Println(NewReplCommand().Help())
 			default:
-				return fmt.Errorf("unknown command: %s", subCmd)
+				return exitError, fmt.Errorf("unknown command: %s", subCmd)
 			}
 		} else {
 			printUsage()
 		}
-		return nil
+		return exitOK, nil
 	default:
-		return fmt.Errorf("unknown command: %s", cmd)
+		return exitError, fmt.Errorf("unknown command: %s", cmd)
 	}
 
 	// Run the command
-	exitCode := command.Run(cmdArgs)
-	if exitCode != 0 {
-		return fmt.Errorf("command failed with exit code %d", exitCode)
-	}
-	return nil
+	return command.Run(cmdArgs), nil
 }
 
 func printUsage() {
@@ -73,12 +88,20 @@ Commands:
   process   Process and evaluate YAML files (default if no command given)
   test      Run fixture tests
   gen       Generate documentation from fixtures
+  explain   Explain how output values were produced
+  lint      Check templates without rendering them
+  diff      Compare the documents of two renderings
+  repl      Evaluate expressions in the scopes of a template
   help      Show help for a command
 
 Examples:
   yamlexpr process config.yaml
   yamlexpr test -dir testdata/fixtures-by-feature
   yamlexpr gen -feature for-loops
+  yamlexpr explain app.yaml services[0].name
+  yamlexpr lint -format sarif app.yaml
+  yamlexpr diff -old-root main -key name app.yaml
+  yamlexpr repl -var env=prod app.yaml
   yamlexpr help process
 
 Use 'yamlexpr help <command>' for detailed help on a command.
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+// stdinName is the file name reading a document from stdin.
+const stdinName = "-"
+
+// ProcessCommand processes YAML files and writes the resulting documents.
+type ProcessCommand struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// processOptions holds the flags of the process command.
+type processOptions struct {
+	root     string
+	output   string
+	format   string
+	schema   string
+	varsFile string
+	vars     varsFlag
+	query    string
+	watch    bool
+	interval time.Duration
+
+	secretsDir       string
+	secretsEnvPrefix string
+}
+
+// NewProcessCommand returns the process command.
+func NewProcessCommand() *ProcessCommand {
+	return &ProcessCommand{
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+}
+
+// Help returns the command usage.
+func (c *ProcessCommand) Help() string {
+	return `Usage: yamlexpr process [options] [file ...]
+
+Process YAML files and write the resulting documents, separated by ---.
+Files are resolved relative to the root directory, as are includes.
+Absolute file names must be in the root directory.
+With no files, or with the file "-", the document is read from stdin.
+
+With -query, the query is evaluated against each resulting document and the
+results are written instead of the documents, one per document. The query is
+a path like spec.replicas or services[0].name, or an expression using the
+document keys as variables, like len(services). String results are written
+unquoted in the yaml format, for use in scripts.
+
+Secrets used with secret("name") are read from files in -secrets-dir, or
+from environment variables with -secrets-env-prefix, e.g. secret("db/password")
+reads SECRET_DB_PASSWORD with the prefix SECRET_.
+
+With -watch, the input files and every file they include are polled for
+changes. On change the files are processed again, and the difference to the
+previous output is printed, or the error if processing fails.
+
+Options:
+` + flagDefaults(c.flagSet(&processOptions{})) + `
+
+Examples:
+  yamlexpr process config.yaml
+  yamlexpr process -root deploy -var env=prod -var replicas=3 app.yaml
+  yamlexpr process -vars-file vars.yaml -format json -o out.json app.yaml
+  yamlexpr process -query services[0].image app.yaml
+  yamlexpr process -query 'map(services, .name)' -format json app.yaml
+  yamlexpr process -secrets-dir /run/secrets app.yaml
+  yamlexpr process -watch -var env=dev app.yaml
+  cat app.yaml | yamlexpr process`
+}
+
+// flagSet returns the flags of the process command, bound to opts.
+func (c *ProcessCommand) flagSet(opts *processOptions) *flag.FlagSet {
+	fs := newFlagSet("process", c.stderr, c.Help)
+	fs.StringVar(&opts.root, "root", ".", "root `directory` for files and includes")
+	fs.StringVar(&opts.output, "o", "", "write output to `file` (default stdout)")
+	fs.StringVar(&opts.format, "format", "yaml", "output `format`: yaml or json")
+	fs.StringVar(&opts.schema, "schema", "", "validate documents against a JSON Schema `file` in the root directory")
+	fs.StringVar(&opts.varsFile, "vars-file", "", "read variables from a YAML or JSON `file`")
+	fs.Var(&opts.vars, "var", "set a variable as `name=value`, the value is parsed as YAML (repeatable)")
+	secretFlags(fs, opts)
+	fs.StringVar(&opts.query, "query", "", "write the result of a path or `expression` evaluated against each document")
+	fs.BoolVar(&opts.watch, "watch", false, "watch the files and includes, printing changes to the output")
+	fs.DurationVar(&opts.interval, "interval", 500*time.Millisecond, "polling `interval` of the watch mode")
+	return fs
+}
+
+// Run runs the command with arguments and returns the exit code.
+func (c *ProcessCommand) Run(args []string) int {
+	var opts processOptions
+	files, err := parseArgs(c.flagSet(&opts), args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	if opts.watch {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if err := c.watch(ctx, &opts, files); err != nil {
+			fmt.Fprintf(c.stderr, "error: %v\n", err)
+			return exitError
+		}
+		return exitOK
+	}
+
+	if err := c.process(&opts, files); err != nil {
+		fmt.Fprintf(c.stderr, "error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// process processes files and writes the resulting documents.
+func (c *ProcessCommand) process(opts *processOptions, files []string) error {
+	out, err := c.render(opts, files, os.DirFS(opts.root))
+	if err != nil {
+		return err
+	}
+	return c.write(opts, out)
+}
+
+// render processes files from fsys and returns the encoded documents.
+func (c *ProcessCommand) render(opts *processOptions, files []string, fsys fs.FS) ([]byte, error) {
+	if opts.format != "yaml" && opts.format != "json" {
+		return nil, fmt.Errorf("unknown output format '%s', expected yaml or json", opts.format)
+	}
+
+	vars, err := opts.variables()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		files = []string{stdinName}
+	}
+
+	configOpts := opts.secretOptions()
+	if opts.schema != "" {
+		configOpts = append(configOpts, yamlexpr.WithSchema(filepath.ToSlash(opts.schema)))
+	}
+	e := yamlexpr.New(fsys, configOpts...)
+
+	var docs []yamlexpr.Document
+	for _, file := range files {
+		filename, err := rootFile(opts.root, file)
+		if err != nil {
+			return nil, err
+		}
+
+		tpl, err := loadTemplate(e, c.stdin, filename)
+		if err != nil {
+			return nil, err
+		}
+		result, err := tpl.Execute(vars)
+		if err != nil {
+			return nil, fmt.Errorf("error processing file %s: %w", filename, err)
+		}
+		docs = append(docs, result...)
+	}
+
+	if opts.query != "" {
+		return encodeQuery(e, docs, opts.query, opts.format)
+	}
+	return encodeDocuments(e, docs, opts.format)
+}
+
+// write writes the output to the output file, or to stdout.
+func (c *ProcessCommand) write(opts *processOptions, out []byte) error {
+	if opts.output != "" {
+		return os.WriteFile(opts.output, out, 0o644)
+	}
+	_, err := c.stdout.Write(out)
+	return err
+}
+
+// loadTemplate loads a template file, or reads it from stdin for the file "-".
+func loadTemplate(e *yamlexpr.Expr, stdin io.Reader, filename string) (*yamlexpr.Template, error) {
+	if filename != stdinName {
+		return e.LoadTemplate(filename)
+	}
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, fmt.Errorf("error reading stdin: %w", err)
+	}
+	return e.CompileYAML(stdinName, data)
+}
+
+// variables returns the variables from the vars file and var flags.
+// Var flags take precedence over the vars file.
+func (o *processOptions) variables() (map[string]any, error) {
+	vars := make(map[string]any)
+	if o.varsFile != "" {
+		data, err := os.ReadFile(o.varsFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading vars file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &vars); err != nil {
+			return nil, fmt.Errorf("error parsing vars file %s: %w", o.varsFile, err)
+		}
+	}
+	for k, v := range o.vars {
+		vars[k] = v
+	}
+	return vars, nil
+}
+
+// encodeDocuments encodes documents in the output format.
+// YAML documents are separated by ---, JSON documents by newlines.
+func encodeDocuments(e *yamlexpr.Expr, docs []yamlexpr.Document, format string) ([]byte, error) {
+	if format == "yaml" {
+		return e.Marshal(docs)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	for i, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return nil, fmt.Errorf("error encoding document %d: %w", i, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeQuery evaluates query against documents and encodes the results in
+// the output format. In the yaml format, scalars are written one per line with
+// strings unquoted, and maps and lists are written as YAML documents.
+func encodeQuery(e *yamlexpr.Expr, docs []yamlexpr.Document, query, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	for i, doc := range docs {
+		result, err := e.Query(doc, query)
+		if err != nil {
+			return nil, fmt.Errorf("error querying document %d: %w", i, err)
+		}
+
+		if format == "json" {
+			if err := enc.Encode(result); err != nil {
+				return nil, fmt.Errorf("error encoding result of document %d: %w", i, err)
+			}
+			continue
+		}
+
+		switch v := result.(type) {
+		case nil:
+			buf.WriteString("null\n")
+		case string:
+			buf.WriteString(v + "\n")
+		case map[string]any, yamlexpr.Document, []any:
+			if buf.Len() > 0 {
+				buf.WriteString("---\n")
+			}
+			out, err := e.MarshalValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("error encoding result of document %d: %w", i, err)
+			}
+			buf.Write(out)
+		default:
+			fmt.Fprintln(&buf, v)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// secretFlags adds the flags configuring the secret function to fs.
+func secretFlags(fs *flag.FlagSet, opts *processOptions) {
+	fs.StringVar(&opts.secretsDir, "secrets-dir", "", "resolve secret(name) from files in `directory`")
+	fs.StringVar(&opts.secretsEnvPrefix, "secrets-env-prefix", "", "resolve secret(name) from environment variables with the `prefix`")
+}
+
+// secretOptions returns the config options enabling the secret function, if
+// a secret source is set. Files take precedence over environment variables.
+func (o *processOptions) secretOptions() []yamlexpr.ConfigOption {
+	var files *yamlexpr.FileSecrets
+	var env *yamlexpr.EnvSecrets
+	if o.secretsDir != "" {
+		files = &yamlexpr.FileSecrets{FS: os.DirFS(o.secretsDir)}
+	}
+	if o.secretsEnvPrefix != "" {
+		env = &yamlexpr.EnvSecrets{Prefix: o.secretsEnvPrefix}
+	}
+
+	switch {
+	case files != nil && env != nil:
+		return []yamlexpr.ConfigOption{yamlexpr.WithSecrets(yamlexpr.SecretProviderFunc(func(name string) (string, error) {
+			if val, err := files.Secret(name); err == nil {
+				return val, nil
+			}
+			return env.Secret(name)
+		}))}
+	case files != nil:
+		return []yamlexpr.ConfigOption{yamlexpr.WithSecrets(files)}
+	case env != nil:
+		return []yamlexpr.ConfigOption{yamlexpr.WithSecrets(env)}
+	}
+	return nil
+}
+
+// varsFlag collects `name=value` variables. Values are parsed as YAML,
+// so `replicas=3` sets an integer and `tags=[a, b]` sets a list.
+type varsFlag map[string]any
+
+// String implements flag.Value.
+func (v varsFlag) String() string {
+	return ""
+}
+
+// Set implements flag.Value.
+func (v *varsFlag) Set(s string) error {
+	name, raw, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected name=value, got '%s'", s)
+	}
+
+	var value any
+	if err := yaml.Unmarshal([]byte(raw), &value); err != nil || value == nil {
+		value = raw
+	}
+
+	if *v == nil {
+		*v = make(varsFlag)
+	}
+	(*v)[name] = value
+	return nil
+}
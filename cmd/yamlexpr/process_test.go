@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProcessCommand(stdin string) (*ProcessCommand, *bytes.Buffer, *bytes.Buffer) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	return &ProcessCommand{
+		stdin:  strings.NewReader(stdin),
+		stdout: stdout,
+		stderr: stderr,
+	}, stdout, stderr
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestProcessCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app.yaml": `inputs:
+  env:
+    default: dev
+  replicas:
+    type: integer
+    required: true
+for: region in ["eu", "us"]
+name: app-${env}-${region}
+replicas: ${replicas}
+`,
+		"base.yaml": "labels:\n  team: core\n",
+		"site.yaml": "include: base.yaml\nname: ${name}\n",
+		"vars.yaml": "env: prod\nname: site\n",
+	})
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		want   string
+		code   int
+		stderr string
+	}{
+		{
+			name: "multiple documents",
+			args: []string{"-root", dir, "app.yaml", "-var", "replicas=3"},
+			want: "name: app-dev-eu\nreplicas: 3\n---\nname: app-dev-us\nreplicas: 3\n",
+		},
+		{
+			name: "vars file and var precedence",
+			args: []string{"-root", dir, "-vars-file", filepath.Join(dir, "vars.yaml"), "-var", "env=stage", "-var", "replicas=1", "app.yaml"},
+			want: "name: app-stage-eu\nreplicas: 1\n---\nname: app-stage-us\nreplicas: 1\n",
+		},
+		{
+			name: "multiple files",
+			args: []string{"-root", dir, "-var", "name=www", "site.yaml", "site.yaml"},
+			want: "labels:\n  team: core\nname: www\n---\nlabels:\n  team: core\nname: www\n",
+		},
+		{
+			name:  "stdin with includes from root",
+			args:  []string{"-root", dir, "-var", "port=80"},
+			stdin: "include: base.yaml\nport: ${port}\n",
+			want:  "labels:\n  team: core\nport: 80\n",
+		},
+		{
+			name:  "json",
+			args:  []string{"-format", "json", "-var", "port=8080", "-"},
+			stdin: "port: ${port}\n",
+			want:  "{\n  \"port\": 8080\n}\n",
+		},
+		{
+			name: "query path",
+			args: []string{"-root", dir, "-var", "replicas=3", "-query", "name", "app.yaml"},
+			want: "app-dev-eu\napp-dev-us\n",
+		},
+		{
+			name: "query expression",
+			args: []string{"-root", dir, "-var", "replicas=3", "-query", "replicas * 2", "app.yaml"},
+			want: "6\n6\n",
+		},
+		{
+			name: "query map",
+			args: []string{"-root", dir, "-var", "name=www", "-query", "labels", "site.yaml", "site.yaml"},
+			want: "team: core\n---\nteam: core\n",
+		},
+		{
+			name:  "query json",
+			args:  []string{"-format", "json", "-query", "ports[1]", "-"},
+			stdin: "ports: [80, 443]\nname: web\n",
+			want:  "443\n",
+		},
+		{
+			name:   "query undefined variable",
+			args:   []string{"-query", "nmae", "-"},
+			stdin:  "name: web\n",
+			code:   exitError,
+			stderr: "error querying document 0: error evaluating query 'nmae'",
+		},
+		{
+			name: "absolute path in root",
+			args: []string{"-root", dir, "-var", "name=www", filepath.Join(dir, "site.yaml")},
+			want: "labels:\n  team: core\nname: www\n",
+		},
+		{
+			name:   "absolute path outside root",
+			args:   []string{"-root", filepath.Join(dir, "sub"), filepath.Join(dir, "site.yaml")},
+			code:   exitError,
+			stderr: "file " + filepath.Join(dir, "site.yaml") + " is outside the root directory " + filepath.Join(dir, "sub") + ", see -root",
+		},
+		{
+			name:   "relative path outside root",
+			args:   []string{"-root", dir, "../site.yaml"},
+			code:   exitError,
+			stderr: "file ../site.yaml is outside the root directory " + dir + ", see -root",
+		},
+		{
+			name:   "missing input",
+			args:   []string{"-root", dir, "app.yaml"},
+			code:   exitError,
+			stderr: "error processing file app.yaml: missing required input 'replicas'",
+		},
+		{
+			name:   "unknown format",
+			args:   []string{"-format", "toml", "app.yaml"},
+			code:   exitError,
+			stderr: "unknown output format 'toml'",
+		},
+		{
+			name:   "invalid var",
+			args:   []string{"-var", "replicas"},
+			code:   exitUsage,
+			stderr: "expected name=value, got 'replicas'",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, stdout, stderr := newTestProcessCommand(tc.stdin)
+
+			code := cmd.Run(tc.args)
+			require.Equal(t, tc.code, code, stderr.String())
+			require.Contains(t, stderr.String(), tc.stderr)
+			if tc.code == exitOK {
+				require.Equal(t, tc.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestProcessCommand_Output(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.yaml": "labels:\n  team: core\n",
+	})
+	output := filepath.Join(t.TempDir(), "out.yaml")
+
+	cmd, stdout, _ := newTestProcessCommand("include: base.yaml\nname: app\n")
+	require.Equal(t, exitOK, cmd.Run([]string{"-root", dir, "-o", output}))
+	require.Empty(t, stdout.String())
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	require.Equal(t, "labels:\n  team: core\nname: app\n", string(data))
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+var errReplStdin = errors.New("repl reads commands from stdin, provide an input file")
+
+// ReplCommand evaluates expressions interactively in the scopes of a template.
+type ReplCommand struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// NewReplCommand returns the repl command.
+func NewReplCommand() *ReplCommand {
+	return &ReplCommand{
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+}
+
+// Help returns the command usage.
+func (c *ReplCommand) Help() string {
+	return `Usage: yamlexpr repl [options] file
+
+Render a YAML file and evaluate expressions in the scope of a path, with the
+variables defined there: the root keys and inputs of the document, and the
+variables of the for loop iterations and matrix jobs enclosing the path.
+Iterations are entered by index, e.g. services[0][1] is the second iteration
+of a for loop in the first services item.
+
+Lines are evaluated as an expression like len(services), or as a string
+interpolation like ${name}-${env}, and the typed result is printed.
+
+Commands:
+  :cd [path]      enter a path relative to the current path, /path from the
+                  document root, .. for the parent; without a path, the root.
+                  The path must be a scope listed by :ls or a rendered value
+  :ls             list the iteration scopes at or below the current path
+  :vars           print the variables in scope
+  :if condition   evaluate a condition like the if directive
+  :help           print the commands
+  :quit           exit
+
+Options:
+` + flagDefaults(c.flagSet(&processOptions{})) + `
+
+Examples:
+  yamlexpr repl app.yaml
+  yamlexpr repl -var env=prod app.yaml`
+}
+
+// flagSet returns the flags of the repl command, bound to opts.
+func (c *ReplCommand) flagSet(opts *processOptions) *flag.FlagSet {
+	fs := newFlagSet("repl", c.stderr, c.Help)
+	fs.StringVar(&opts.root, "root", ".", "root `directory` for files and includes")
+	fs.StringVar(&opts.varsFile, "vars-file", "", "read variables from a YAML or JSON `file`")
+	fs.Var(&opts.vars, "var", "set a variable as `name=value`, the value is parsed as YAML (repeatable)")
+	secretFlags(fs, opts)
+	return fs
+}
+
+// Run runs the command with arguments and returns the exit code.
+func (c *ReplCommand) Run(args []string) int {
+	var opts processOptions
+	fs := c.flagSet(&opts)
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	scopes, paths, err := c.scopes(&opts, files[0])
+	if err != nil {
+		fmt.Fprintf(c.stderr, "error: %v\n", err)
+		return exitError
+	}
+	c.repl(scopes, paths)
+	return exitOK
+}
+
+// scopes renders file and returns the scopes of the template, and the paths
+// that can be entered with :cd.
+func (c *ReplCommand) scopes(opts *processOptions, file string) ([]*yamlexpr.Scope, map[string]bool, error) {
+	if file == stdinName {
+		return nil, nil, errReplStdin
+	}
+	filename, err := rootFile(opts.root, file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vars, err := opts.variables()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e := yamlexpr.New(os.DirFS(opts.root), opts.secretOptions()...)
+	tpl, err := e.LoadTemplate(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	scopes, err := tpl.Scopes(vars)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error processing file %s: %w", filename, err)
+	}
+	docs, err := tpl.Execute(vars)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error processing file %s: %w", filename, err)
+	}
+
+	// Scope paths, their parents and the paths of rendered values can be entered
+	paths := map[string]bool{"": true}
+	for _, s := range scopes {
+		for path := s.Path; path != ""; path = parentReplPath(path) {
+			paths[path] = true
+		}
+	}
+	for _, doc := range docs {
+		valuePaths(paths, "", doc.Map())
+	}
+	return scopes, paths, nil
+}
+
+// repl reads lines from stdin and evaluates them until :quit or the end of input.
+func (c *ReplCommand) repl(scopes []*yamlexpr.Scope, paths map[string]bool) {
+	path := ""
+	scope := scopes[0]
+	scanner := bufio.NewScanner(c.stdin)
+	for {
+		fmt.Fprintf(c.stdout, "%s> ", path)
+		if !scanner.Scan() {
+			fmt.Fprintln(c.stdout)
+			return
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		command, arg, _ := strings.Cut(line, " ")
+		arg = strings.TrimSpace(arg)
+
+		switch command {
+		case "":
+		case ":quit", ":q":
+			return
+		case ":help":
+			fmt.Fprintln(c.stdout, "commands: :cd [path], :ls, :vars, :if condition, :help, :quit")
+		case ":cd":
+			target := joinReplPath(path, arg)
+			if !paths[target] {
+				fmt.Fprintf(c.stderr, "error: unknown path %s, see :ls\n", target)
+				continue
+			}
+			path = target
+			scope = scopeAt(scopes, path)
+			if scope.Path != "" {
+				fmt.Fprintf(c.stdout, "scope %s\n", scope.Path)
+			}
+			writeDetails(c.stdout, "", scope.Steps)
+		case ":ls":
+			for _, s := range scopes {
+				if s.Path != "" && (path == "" || pathHasPrefix(s.Path, path)) {
+					fmt.Fprintln(c.stdout, s.Path)
+				}
+			}
+		case ":vars":
+			vars := scope.Vars()
+			names := make([]string, 0, len(vars))
+			for name := range vars {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fmt.Fprintf(c.stdout, "%s = %s\n", name, formatValue(vars[name]))
+			}
+		case ":if":
+			ok, err := scope.Condition(arg)
+			if err != nil {
+				fmt.Fprintf(c.stderr, "error: %v\n", err)
+				continue
+			}
+			fmt.Fprintln(c.stdout, ok)
+		default:
+			if strings.HasPrefix(command, ":") {
+				fmt.Fprintf(c.stderr, "error: unknown command %s, see :help\n", command)
+				continue
+			}
+			val, err := scope.Eval(line)
+			if err != nil {
+				fmt.Fprintf(c.stderr, "error: %v\n", err)
+				continue
+			}
+			fmt.Fprintf(c.stdout, "%s (%s)\n", formatValue(val), typeName(val))
+		}
+	}
+}
+
+// joinReplPath returns the path entered by :cd from the current path.
+func joinReplPath(current, arg string) string {
+	switch {
+	case arg == "" || arg == "/":
+		return ""
+	case arg == "..":
+		return parentReplPath(current)
+	case strings.HasPrefix(arg, "/"):
+		return strings.TrimPrefix(arg, "/")
+	case current == "" || strings.HasPrefix(arg, "["):
+		return current + arg
+	}
+	return current + "." + arg
+}
+
+// parentReplPath returns the parent of path, or "" for top level paths.
+func parentReplPath(path string) string {
+	if i := max(strings.LastIndex(path, "."), strings.LastIndex(path, "[")); i > 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// valuePaths adds the paths of value and the values below it to paths.
+func valuePaths(paths map[string]bool, path string, value any) {
+	paths[path] = true
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if path != "" {
+				key = path + "." + key
+			}
+			valuePaths(paths, key, item)
+		}
+	case []any:
+		for i, item := range v {
+			valuePaths(paths, fmt.Sprintf("%s[%d]", path, i), item)
+		}
+	}
+}
+
+// scopeAt returns the innermost scope enclosing path.
+func scopeAt(scopes []*yamlexpr.Scope, path string) *yamlexpr.Scope {
+	result := scopes[0]
+	for _, s := range scopes[1:] {
+		if pathHasPrefix(path, s.Path) && len(s.Path) > len(result.Path) {
+			result = s
+		}
+	}
+	return result
+}
+
+// typeName returns the type of an evaluated value.
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "map"
+	case []any:
+		return "list"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app.yaml": `name: app
+services:
+  - for: svc in ["api", "web"]
+    name: ${svc}-${env}
+    ports:
+      - for: port in [80, 443]
+        port: ${port}
+`,
+	})
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		want   string
+		code   int
+		stderr string
+	}{
+		{
+			name:  "root scope",
+			args:  []string{"-root", dir, "-var", "env=prod", "app.yaml"},
+			stdin: "name\nlen(services)\n${name}-${env}\n:if env == \"prod\"\n",
+			want: "> \"app\" (string)\n" +
+				"> 1 (int)\n" +
+				"> \"app-prod\" (string)\n" +
+				"> true\n" +
+				"> \n",
+		},
+		{
+			name:  "iteration scopes",
+			args:  []string{"-root", dir, "-var", "env=prod", "app.yaml"},
+			stdin: ":ls\n:cd services[0][1]\n:cd ports[0][0]\n:vars\n:cd /\n:quit\nname\n",
+			want: "> services[0][0]\n" +
+				"services[0][0].ports[0][0]\n" +
+				"services[0][0].ports[0][1]\n" +
+				"services[0][1]\n" +
+				"services[0][1].ports[0][0]\n" +
+				"services[0][1].ports[0][1]\n" +
+				"> scope services[0][1]\n" +
+				"    for: svc in [\"api\", \"web\"], iteration 1 (svc=\"web\")\n" +
+				"services[0][1]> scope services[0][1].ports[0][0]\n" +
+				"    for: svc in [\"api\", \"web\"], iteration 1 (svc=\"web\")\n" +
+				"    for: port in [80, 443], iteration 0 (port=80)\n" +
+				"services[0][1].ports[0][0]> env = \"prod\"\n" +
+				"name = \"app\"\n" +
+				"port = 80\n" +
+				"services = [{\"for\":\"svc in [\\\"api\\\", \\\"web\\\"]\",\"name\":\"${svc}-${env}\",\"ports\":[{\"for\":\"port in [80, 443]\",\"port\":\"${port}\"}]}]\n" +
+				"svc = \"web\"\n" +
+				"services[0][1].ports[0][0]> > ",
+		},
+		{
+			name:  "unknown paths",
+			args:  []string{"-root", dir, "-var", "env=prod", "app.yaml"},
+			stdin: ":cd services[0][1]\n:cd services[0][0]\n:cd bogus[7]\n:cd /services[0][0]\n:cd /services[1].ports\nname\n",
+			want: "> scope services[0][1]\n" +
+				"    for: svc in [\"api\", \"web\"], iteration 1 (svc=\"web\")\n" +
+				"services[0][1]> services[0][1]> services[0][1]> scope services[0][0]\n" +
+				"    for: svc in [\"api\", \"web\"], iteration 0 (svc=\"api\")\n" +
+				"services[0][0]> services[1].ports> \"app\" (string)\n" +
+				"services[1].ports> \n",
+			stderr: "error: unknown path services[0][1].services[0][0], see :ls\n" +
+				"error: unknown path services[0][1].bogus[7], see :ls\n",
+		},
+		{
+			name:   "evaluation errors",
+			args:   []string{"-root", dir, "-var", "env=prod", "app.yaml"},
+			stdin:  "svc\n:nope\n",
+			want:   "> > > \n",
+			stderr: "error: error evaluating 'svc': unknown name svc (1:1)\n | svc\n | ^\nerror: unknown command :nope, see :help\n",
+		},
+		{
+			name:   "processing error",
+			args:   []string{"-root", dir, "app.yaml"},
+			code:   exitError,
+			stderr: "error: error processing file app.yaml: ",
+		},
+		{
+			name:   "stdin",
+			args:   []string{"-"},
+			code:   exitError,
+			stderr: "error: repl reads commands from stdin, provide an input file\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			cmd := &ReplCommand{
+				stdin:  strings.NewReader(tc.stdin),
+				stdout: stdout,
+				stderr: stderr,
+			}
+
+			code := cmd.Run(tc.args)
+			require.Equal(t, tc.code, code, stderr.String())
+			require.Equal(t, tc.want, stdout.String())
+			if tc.code == exitOK {
+				require.Equal(t, tc.stderr, stderr.String())
+				return
+			}
+			require.Contains(t, stderr.String(), tc.stderr)
+		})
+	}
+}
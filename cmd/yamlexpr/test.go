@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/titpetric/yamlexpr/fixture"
+)
+
+// TestCommand runs fixture files and compares the results with the expected documents.
+type TestCommand struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// testOptions holds the flags of the test command.
+type testOptions struct {
+	dir    string
+	run    string
+	update bool
+}
+
+// NewTestCommand returns the test command.
+func NewTestCommand() *TestCommand {
+	return &TestCommand{
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+}
+
+// Help returns the command usage.
+func (c *TestCommand) Help() string {
+	return `Usage: yamlexpr test [options] [dir|file ...]
+
+Run fixture files and compare the resulting documents with the expected output.
+A fixture holds frontmatter, an input template and the expected documents,
+separated by ---. Documents are compared by value, differences are printed
+as unified diffs. Files starting with _ are includes, not fixtures.
+
+With -update, the expected sections of fixtures with differing output are
+rewritten with the actual documents, keeping the frontmatter and input.
+
+Options:
+` + flagDefaults(c.flagSet(&testOptions{})) + `
+
+Examples:
+  yamlexpr test
+  yamlexpr test -dir testdata/fixtures-by-feature
+  yamlexpr test -run 'for-loops/' testdata/fixtures-by-feature
+  yamlexpr test -update testdata/fixtures/041-for-single-item.yaml`
+}
+
+// flagSet returns the flags of the test command, bound to opts.
+func (c *TestCommand) flagSet(opts *testOptions) *flag.FlagSet {
+	fs := newFlagSet("test", c.stderr, c.Help)
+	fs.StringVar(&opts.dir, "dir", "testdata/fixtures", "fixture `directory`, used when no files are given")
+	fs.StringVar(&opts.run, "run", "", "run only fixtures with names matching the `regexp`")
+	fs.BoolVar(&opts.update, "update", false, "update the expected sections of fixtures with differing output")
+	return fs
+}
+
+// Run runs the command with arguments and returns the exit code.
+func (c *TestCommand) Run(args []string) int {
+	var opts testOptions
+	paths, err := parseArgs(c.flagSet(&opts), args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	fixtures, err := c.load(&opts, paths)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "error: %v\n", err)
+		return exitError
+	}
+
+	if !c.test(fixtures, opts.update) {
+		return exitError
+	}
+	return exitOK
+}
+
+// load loads the fixtures in paths, or in the fixture directory if no paths are given.
+// Fixtures with names not matching the run filter are skipped.
+func (c *TestCommand) load(opts *testOptions, paths []string) ([]*fixture.Fixture, error) {
+	var filter *regexp.Regexp
+	if opts.run != "" {
+		var err error
+		if filter, err = regexp.Compile(opts.run); err != nil {
+			return nil, fmt.Errorf("invalid -run pattern: %w", err)
+		}
+	}
+
+	if len(paths) == 0 {
+		paths = []string{opts.dir}
+	}
+
+	var fixtures []*fixture.Fixture
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var loaded []*fixture.Fixture
+		if info.IsDir() {
+			loaded, err = fixture.LoadDir(path)
+		} else {
+			var f *fixture.Fixture
+			f, err = fixture.Load(path)
+			loaded = []*fixture.Fixture{f}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range loaded {
+			if filter == nil || filter.MatchString(f.Name) {
+				fixtures = append(fixtures, f)
+			}
+		}
+	}
+	return fixtures, nil
+}
+
+// test runs fixtures, printing a result for each fixture and a summary.
+// With update, fixtures with differing output are updated instead of failing.
+// It returns true if all fixtures passed or were updated.
+func (c *TestCommand) test(fixtures []*fixture.Fixture, update bool) bool {
+	failed, updated := 0, 0
+	for _, f := range fixtures {
+		r := f.Run()
+		if r.Passed() {
+			fmt.Fprintf(c.stdout, "--- PASS: %s\n", f.Name)
+			continue
+		}
+
+		if update && r.Err == nil {
+			if err := f.Update(r.Actual); err != nil {
+				failed++
+				fmt.Fprintf(c.stdout, "--- FAIL: %s (%s)\n    error: %v\n", f.Name, f.Path, err)
+				continue
+			}
+			updated++
+			fmt.Fprintf(c.stdout, "--- UPDATE: %s (%s)\n", f.Name, f.Path)
+			continue
+		}
+
+		failed++
+		fmt.Fprintf(c.stdout, "--- FAIL: %s (%s)\n", f.Name, f.Path)
+		if r.Err != nil {
+			fmt.Fprintf(c.stdout, "    error: %v\n", r.Err)
+			continue
+		}
+		fmt.Fprintln(c.stdout, indent(r.Diff, "    "))
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(c.stdout, "FAIL: %d of %d fixtures failed\n", failed, len(fixtures))
+		return false
+	}
+	if updated > 0 {
+		fmt.Fprintf(c.stdout, "PASS: %d fixtures, %d updated\n", len(fixtures), updated)
+		return true
+	}
+	fmt.Fprintf(c.stdout, "PASS: %d fixtures\n", len(fixtures))
+	return true
+}
+
+// indent prefixes each line of text.
+func indent(text, prefix string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"_base.yaml": "team: core\n",
+		"pass.yaml":  "---\ntitle: Pass\n---\ninclude: _base.yaml\nname: ${'app'}\n---\nname: \"app\"\nteam: core\n",
+		"fail.yaml":  "---\ntitle: Fail\n---\nport: ${8000 + 80}\n---\nport: 8081\n",
+	})
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+		want []string
+	}{
+		{
+			name: "all fixtures",
+			args: []string{dir},
+			code: exitError,
+			want: []string{
+				"--- FAIL: fail (",
+				"    -port: 8081\n    +port: 8080\n",
+				"--- PASS: pass\n",
+				"FAIL: 1 of 2 fixtures failed\n",
+			},
+		},
+		{
+			name: "run filter",
+			args: []string{"-run", "^pass$", "-dir", dir},
+			code: exitOK,
+			want: []string{"--- PASS: pass\n", "PASS: 1 fixtures\n"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			cmd := &TestCommand{stdout: stdout, stderr: stderr}
+
+			require.Equal(t, tc.code, cmd.Run(tc.args), stderr.String())
+			for _, want := range tc.want {
+				require.Contains(t, stdout.String(), want)
+			}
+		})
+	}
+}
+
+func TestTestCommand_Update(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"pass.yaml": "---\ntitle: Pass\n---\nname: ${'app'}\n---\nname: \"app\"\n",
+		"fail.yaml": "---\ntitle: Fail # kept\n---\nport: ${8000 + 80}\n---\nport: 8081\n",
+		"err.yaml":  "---\ntitle: Error\n---\nitems:\n  - for: v in missing\n---\nitems: []\n",
+	})
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := &TestCommand{stdout: stdout, stderr: stderr}
+
+	require.Equal(t, exitError, cmd.Run([]string{"-update", dir}), stderr.String())
+	require.Contains(t, stdout.String(), "--- FAIL: err (")
+	require.Contains(t, stdout.String(), "--- UPDATE: fail (")
+	require.Contains(t, stdout.String(), "FAIL: 1 of 3 fixtures failed\n")
+
+	data, err := os.ReadFile(filepath.Join(dir, "fail.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, "---\ntitle: Fail # kept\n---\nport: ${8000 + 80}\n---\nport: 8080\n", string(data))
+
+	data, err = os.ReadFile(filepath.Join(dir, "pass.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, "---\ntitle: Pass\n---\nname: ${'app'}\n---\nname: \"app\"\n", string(data))
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "err.yaml")))
+	stdout.Reset()
+	require.Equal(t, exitOK, cmd.Run([]string{dir}))
+	require.Contains(t, stdout.String(), "PASS: 2 fixtures\n")
+}
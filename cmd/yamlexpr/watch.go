@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// errWatchStdin is returned when watching input from stdin.
+var errWatchStdin = errors.New("watch mode can't read from stdin, provide input files")
+
+// watcher re-renders the process command output when a watched file changes.
+// Watched files are the input files, the vars file and every file read while
+// rendering, which includes the include chain and the schema.
+type watcher struct {
+	cmd   *ProcessCommand
+	opts  *processOptions
+	files []string
+
+	// stamps holds the state of the watched files at the last render.
+	stamps map[string]fileStamp
+	// output is the last successfully rendered output.
+	output []byte
+	// rendered is true after the first successful render.
+	rendered bool
+}
+
+// fileStamp identifies a version of a file. Missing files have a zero stamp.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// recordingFS is a filesystem recording the names of opened files.
+type recordingFS struct {
+	fs.FS
+	names map[string]bool
+}
+
+// Open implements fs.FS.
+func (r *recordingFS) Open(name string) (fs.File, error) {
+	r.names[name] = true
+	return r.FS.Open(name)
+}
+
+// watch renders files, then polls the watched files at the interval and
+// re-renders on change until the context is cancelled.
+func (c *ProcessCommand) watch(ctx context.Context, opts *processOptions, files []string) error {
+	if len(files) == 0 {
+		return errWatchStdin
+	}
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		if file == stdinName {
+			return errWatchStdin
+		}
+		name, err := rootFile(opts.root, file)
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+	}
+	if opts.interval <= 0 {
+		return fmt.Errorf("invalid interval %s, expected a positive duration", opts.interval)
+	}
+
+	w := &watcher{
+		cmd:   c,
+		opts:  opts,
+		files: names,
+	}
+	w.render()
+
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if changed := w.changed(); len(changed) > 0 {
+				fmt.Fprintf(c.stderr, "changed: %s\n", strings.Join(changed, ", "))
+				w.render()
+			}
+		}
+	}
+}
+
+// render renders the output and records the watched files. The first output
+// is written in full, later outputs are printed as a diff against the last
+// successful output. Errors are printed and don't stop watching.
+func (w *watcher) render() {
+	fsys := &recordingFS{
+		FS:    os.DirFS(w.opts.root),
+		names: make(map[string]bool),
+	}
+	out, err := w.cmd.render(w.opts, w.files, fsys)
+
+	paths := make([]string, 0, len(fsys.names)+len(w.files)+1)
+	for _, file := range w.files {
+		paths = append(paths, filepath.Join(w.opts.root, filepath.FromSlash(file)))
+	}
+	for name := range fsys.names {
+		paths = append(paths, filepath.Join(w.opts.root, filepath.FromSlash(name)))
+	}
+	if w.opts.varsFile != "" {
+		paths = append(paths, w.opts.varsFile)
+	}
+	w.stamps = make(map[string]fileStamp, len(paths))
+	for _, path := range paths {
+		w.stamps[path] = stat(path)
+	}
+
+	if err != nil {
+		fmt.Fprintf(w.cmd.stderr, "error: %v\n", err)
+		return
+	}
+
+	if w.opts.output != "" {
+		if err := os.WriteFile(w.opts.output, out, 0o644); err != nil {
+			fmt.Fprintf(w.cmd.stderr, "error: %v\n", err)
+			return
+		}
+	}
+
+	switch {
+	case !w.rendered && w.opts.output == "":
+		w.cmd.stdout.Write(out)
+	case !w.rendered:
+		fmt.Fprintf(w.cmd.stderr, "wrote %s\n", w.opts.output)
+	case string(out) == string(w.output):
+		fmt.Fprintln(w.cmd.stderr, "no changes in output")
+	default:
+		fmt.Fprint(w.cmd.stdout, outputDiff(w.output, out))
+	}
+	w.output = out
+	w.rendered = true
+}
+
+// changed returns the sorted paths of watched files that changed since the last render.
+func (w *watcher) changed() []string {
+	var changed []string
+	for path, stamp := range w.stamps {
+		if stat(path) != stamp {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// stat returns the stamp of a file.
+func stat(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}
+}
+
+// outputDiff returns a unified diff between the previous and current output.
+func outputDiff(previous, current []byte) string {
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(previous)),
+		B:        difflib.SplitLines(string(current)),
+		FromFile: "previous",
+		ToFile:   "current",
+		Context:  3,
+	})
+	return diff
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app.yaml":   "include: base.yaml\nname: app\n",
+		"base.yaml":  "labels:\n  team: core\n",
+		"other.yaml": "unrelated: true\n",
+	})
+
+	cmd, stdout, stderr := newTestProcessCommand("")
+	w := &watcher{
+		cmd:   cmd,
+		opts:  &processOptions{root: dir, format: "yaml"},
+		files: []string{"app.yaml"},
+	}
+
+	w.render()
+	require.Equal(t, "labels:\n  team: core\nname: app\n", stdout.String())
+	require.Empty(t, w.changed())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.yaml"), []byte("unrelated: false\n"), 0o644))
+	require.Empty(t, w.changed())
+
+	stdout.Reset()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "base.yaml"), []byte("labels:\n  team: platform\n"), 0o644))
+	require.Equal(t, []string{filepath.Join(dir, "base.yaml")}, w.changed())
+	w.render()
+	require.Contains(t, stdout.String(), "--- previous\n+++ current\n")
+	require.Contains(t, stdout.String(), "-  team: core\n+  team: platform\n")
+
+	stdout.Reset()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte("include: base.yaml\nname: ${missing + 1}\n"), 0o644))
+	require.Equal(t, []string{filepath.Join(dir, "app.yaml")}, w.changed())
+	w.render()
+	require.Empty(t, stdout.String())
+	require.Contains(t, stderr.String(), "error: error processing file app.yaml")
+
+	stderr.Reset()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte("include: base.yaml\nname: app\n"), 0o644))
+	require.NotEmpty(t, w.changed())
+	w.render()
+	require.Empty(t, stdout.String())
+	require.Equal(t, "no changes in output\n", stderr.String())
+}
+
+func TestProcessCommand_Watch(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app.yaml": "name: app\n",
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		cmd, _, stderr := newTestProcessCommand("name: app\n")
+		require.Equal(t, exitError, cmd.Run([]string{"-watch"}))
+		require.Contains(t, stderr.String(), "watch mode can't read from stdin")
+	})
+
+	t.Run("invalid interval", func(t *testing.T) {
+		cmd, _, stderr := newTestProcessCommand("")
+		require.Equal(t, exitError, cmd.Run([]string{"-watch", "-interval", "0s", "-root", dir, "app.yaml"}))
+		require.Contains(t, stderr.String(), "invalid interval 0s")
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		cmd, stdout, _ := newTestProcessCommand("")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		opts := &processOptions{root: dir, format: "yaml", interval: time.Millisecond}
+		require.NoError(t, cmd.watch(ctx, opts, []string{"app.yaml"}))
+		require.Equal(t, "name: app\n", stdout.String())
+	})
+}
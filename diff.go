@@ -0,0 +1,208 @@
+package yamlexpr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/titpetric/yamlexpr/stack"
+)
+
+// ChangeKind is the kind of a Change or a DocumentDiff.
+type ChangeKind string
+
+// Change kinds.
+const (
+	// ChangeAdded is a value or document only present in the new rendering.
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved is a value or document only present in the old rendering.
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeChanged is a value or document present in both renderings, with a different value.
+	ChangeChanged ChangeKind = "changed"
+)
+
+// Change is a difference between two values at a path.
+type Change struct {
+	// Kind is the kind of change.
+	Kind ChangeKind `json:"kind" yaml:"kind"`
+	// Path is the path of the value in the document, e.g. "spec.ports[0].port".
+	Path string `json:"path" yaml:"path"`
+	// Old is the old value, nil for added values.
+	Old any `json:"old,omitempty" yaml:"old,omitempty"`
+	// New is the new value, nil for removed values.
+	New any `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+// DocumentDiff is the difference between two renderings of a document.
+type DocumentDiff struct {
+	// Kind is the kind of change of the document.
+	Kind ChangeKind `json:"kind" yaml:"kind"`
+	// Key is the identity of the document, e.g. "name=api", empty when documents are matched by index.
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+	// OldIndex is the index of the old document, -1 for added documents.
+	OldIndex int `json:"old_index" yaml:"old_index"`
+	// NewIndex is the index of the new document, -1 for removed documents.
+	NewIndex int `json:"new_index" yaml:"new_index"`
+	// Changes are the changed paths of changed documents, in path order.
+	Changes []Change `json:"changes,omitempty" yaml:"changes,omitempty"`
+}
+
+// Diff compares two renderings of a template and returns the documents that
+// were added, removed or changed. Unchanged documents are omitted.
+//
+// Documents are matched by the values at the key paths, e.g. "name" or
+// "metadata.name", with several keys identifying matrix jobs like "os" and
+// "arch". Without keys, or for documents missing a key, documents are matched
+// by index.
+func Diff(oldDocs, newDocs []Document, keys ...string) []DocumentDiff {
+	oldKeys := documentKeys(oldDocs, keys)
+	newKeys := documentKeys(newDocs, keys)
+
+	newIndex := make(map[string]int, len(newDocs))
+	for i, key := range newKeys {
+		newIndex[key] = i
+	}
+
+	var result []DocumentDiff
+	matched := make(map[string]bool, len(oldDocs))
+	for i, key := range oldKeys {
+		j, ok := newIndex[key]
+		if !ok {
+			result = append(result, DocumentDiff{Kind: ChangeRemoved, Key: identity(key), OldIndex: i, NewIndex: -1})
+			continue
+		}
+		matched[key] = true
+
+		changes := diffValues(nil, "", oldDocs[i].values, newDocs[j].values)
+		if len(changes) > 0 {
+			result = append(result, DocumentDiff{Kind: ChangeChanged, Key: identity(key), OldIndex: i, NewIndex: j, Changes: changes})
+		}
+	}
+	for j, key := range newKeys {
+		if !matched[key] {
+			result = append(result, DocumentDiff{Kind: ChangeAdded, Key: identity(key), OldIndex: -1, NewIndex: j})
+		}
+	}
+	return result
+}
+
+// documentKeys returns the identity of each document. Documents missing a
+// key are identified by index, and repeated identities are numbered.
+func documentKeys(docs []Document, keys []string) []string {
+	result := make([]string, len(docs))
+	seen := make(map[string]int, len(docs))
+	for i, doc := range docs {
+		key := documentKey(doc, keys)
+		if key == "" {
+			result[i] = fmt.Sprintf("#%d", i)
+			continue
+		}
+		seen[key]++
+		if n := seen[key]; n > 1 {
+			key = fmt.Sprintf("%s#%d", key, n)
+		}
+		result[i] = key
+	}
+	return result
+}
+
+// documentKey returns the identity of a document as `key=value` pairs,
+// empty if keys are empty or the document is missing a key.
+func documentKey(doc Document, keys []string) string {
+	if len(keys) == 0 {
+		return ""
+	}
+	st := stack.NewStack(doc.values)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		val, ok := st.Resolve(key)
+		if !ok {
+			return ""
+		}
+		pairs[i] = fmt.Sprintf("%s=%v", key, val)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// identity returns the reported key of a document, empty for index matches.
+func identity(key string) string {
+	if strings.HasPrefix(key, "#") {
+		return ""
+	}
+	return key
+}
+
+// diffValues appends the changes between two values at path.
+// Maps are compared by key, slices by index.
+func diffValues(changes []Change, path string, oldValue, newValue any) []Change {
+	switch o := oldValue.(type) {
+	case map[string]any:
+		n, ok := newValue.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(o)+len(n))
+		for k := range o {
+			keys = append(keys, k)
+		}
+		for k := range n {
+			if _, ok := o[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			ov, inOld := o[k]
+			nv, inNew := n[k]
+			switch {
+			case !inOld:
+				changes = append(changes, Change{Kind: ChangeAdded, Path: joinPath(path, k), New: nv})
+			case !inNew:
+				changes = append(changes, Change{Kind: ChangeRemoved, Path: joinPath(path, k), Old: ov})
+			default:
+				changes = diffValues(changes, joinPath(path, k), ov, nv)
+			}
+		}
+		return changes
+	case []any:
+		n, ok := newValue.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < max(len(o), len(n)); i++ {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(o):
+				changes = append(changes, Change{Kind: ChangeAdded, Path: itemPath, New: n[i]})
+			case i >= len(n):
+				changes = append(changes, Change{Kind: ChangeRemoved, Path: itemPath, Old: o[i]})
+			default:
+				changes = diffValues(changes, itemPath, o[i], n[i])
+			}
+		}
+		return changes
+	}
+
+	if !scalarsEqual(oldValue, newValue) {
+		changes = append(changes, Change{Kind: ChangeChanged, Path: path, Old: oldValue, New: newValue})
+	}
+	return changes
+}
+
+// scalarsEqual reports whether two values are equal. Maps and slices are
+// only equal to themselves, as they are compared by diffValues.
+func scalarsEqual(a, b any) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	switch a.(type) {
+	case map[string]any, []any:
+		return false
+	}
+	switch b.(type) {
+	case map[string]any, []any:
+		return false
+	}
+	return valuesEqual(a, b)
+}
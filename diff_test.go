@@ -0,0 +1,124 @@
+package yamlexpr_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+func TestDiff(t *testing.T) {
+	api := map[string]any{
+		"name":     "api",
+		"replicas": 2,
+		"labels":   map[string]any{"team": "core"},
+		"ports":    []any{80},
+	}
+	worker := map[string]any{"name": "worker", "replicas": 1}
+
+	tests := []struct {
+		name string
+		old  []map[string]any
+		new  []map[string]any
+		keys []string
+		want []yamlexpr.DocumentDiff
+	}{
+		{
+			name: "unchanged",
+			old:  []map[string]any{api, worker},
+			new:  []map[string]any{api, worker},
+			keys: []string{"name"},
+		},
+		{
+			name: "changed paths",
+			old:  []map[string]any{api},
+			new: []map[string]any{{
+				"name":     "api",
+				"replicas": 3,
+				"labels":   map[string]any{"tier": "web"},
+				"ports":    []any{80, 443},
+			}},
+			keys: []string{"name"},
+			want: []yamlexpr.DocumentDiff{{
+				Kind:     yamlexpr.ChangeChanged,
+				Key:      "name=api",
+				OldIndex: 0,
+				NewIndex: 0,
+				Changes: []yamlexpr.Change{
+					{Kind: yamlexpr.ChangeRemoved, Path: "labels.team", Old: "core"},
+					{Kind: yamlexpr.ChangeAdded, Path: "labels.tier", New: "web"},
+					{Kind: yamlexpr.ChangeAdded, Path: "ports[1]", New: 443},
+					{Kind: yamlexpr.ChangeChanged, Path: "replicas", Old: 2, New: 3},
+				},
+			}},
+		},
+		{
+			name: "matched by key",
+			old:  []map[string]any{api, worker},
+			new:  []map[string]any{{"name": "cache"}, api},
+			keys: []string{"name"},
+			want: []yamlexpr.DocumentDiff{
+				{Kind: yamlexpr.ChangeRemoved, Key: "name=worker", OldIndex: 1, NewIndex: -1},
+				{Kind: yamlexpr.ChangeAdded, Key: "name=cache", OldIndex: -1, NewIndex: 0},
+			},
+		},
+		{
+			name: "matched by index",
+			old:  []map[string]any{api, worker},
+			new:  []map[string]any{api},
+			want: []yamlexpr.DocumentDiff{
+				{Kind: yamlexpr.ChangeRemoved, OldIndex: 1, NewIndex: -1},
+			},
+		},
+		{
+			name: "composite key",
+			old: []map[string]any{
+				{"job": map[string]any{"os": "linux", "arch": "amd64"}, "image": "a"},
+				{"job": map[string]any{"os": "linux", "arch": "arm64"}, "image": "a"},
+			},
+			new: []map[string]any{
+				{"job": map[string]any{"os": "linux", "arch": "arm64"}, "image": "b"},
+				{"job": map[string]any{"os": "linux", "arch": "amd64"}, "image": "a"},
+			},
+			keys: []string{"job.os", "job.arch"},
+			want: []yamlexpr.DocumentDiff{{
+				Kind:     yamlexpr.ChangeChanged,
+				Key:      "job.os=linux,job.arch=arm64",
+				OldIndex: 1,
+				NewIndex: 0,
+				Changes: []yamlexpr.Change{
+					{Kind: yamlexpr.ChangeChanged, Path: "image", Old: "a", New: "b"},
+				},
+			}},
+		},
+		{
+			name: "type change",
+			old:  []map[string]any{{"port": "80"}},
+			new:  []map[string]any{{"port": 80}},
+			want: []yamlexpr.DocumentDiff{{
+				Kind:     yamlexpr.ChangeChanged,
+				OldIndex: 0,
+				NewIndex: 0,
+				Changes: []yamlexpr.Change{
+					{Kind: yamlexpr.ChangeChanged, Path: "port", Old: "80", New: 80},
+				},
+			}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, yamlexpr.Diff(documents(tc.old), documents(tc.new), tc.keys...))
+		})
+	}
+}
+
+// documents returns the maps as documents with sorted keys.
+func documents(maps []map[string]any) []yamlexpr.Document {
+	docs := make([]yamlexpr.Document, len(maps))
+	for i, m := range maps {
+		docs[i] = yamlexpr.NewDocument(m)
+	}
+	return docs
+}
@@ -0,0 +1,110 @@
+package yamlexpr_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+// TestExpr_WithEnv tests environment variable access from expressions.
+func TestExpr_WithEnv(t *testing.T) {
+	environ := map[string]string{
+		"HOME":    "/home/app",
+		"APP_ENV": "production",
+		"SECRET":  "hunter2",
+	}
+	lookup := func(name string) (string, bool) {
+		val, ok := environ[name]
+		return val, ok
+	}
+
+	e := yamlexpr.New(nil, yamlexpr.WithEnv(&yamlexpr.EnvOptions{
+		Prefix: "APP_",
+		Allow:  []string{"HOME"},
+		Lookup: lookup,
+	}))
+
+	t.Run("member access", func(t *testing.T) {
+		docs, err := e.Parse(map[string]any{
+			"home": "${env.HOME}",
+			"path": "${env.HOME}/.config",
+			"mode": `${env["APP_ENV"]}`,
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{
+			"home": "/home/app",
+			"path": "/home/app/.config",
+			"mode": "production",
+		}, docs[0].Map())
+	})
+
+	t.Run("default value", func(t *testing.T) {
+		docs, err := e.Parse(map[string]any{
+			"host": `${env("APP_DB_HOST", "localhost")}`,
+			"home": `${env("HOME", "/root")}`,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "localhost", docs[0].Map()["host"])
+		require.Equal(t, "/home/app", docs[0].Map()["home"])
+
+		docs, err = e.Parse(map[string]any{
+			"host": `${ env.APP_DB_HOST ?? "localhost" }`,
+			"port": `${ env["APP_DB_PORT"] ?? 5432 }`,
+			"home": `${ env.HOME ?? "/root" }`,
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{
+			"host": "localhost",
+			"port": 5432,
+			"home": "/home/app",
+		}, docs[0].Map())
+
+		_, err = e.Parse(map[string]any{"secret": `${ env.SECRET ?? "x" }`})
+		require.ErrorContains(t, err, "not allowed")
+	})
+
+	t.Run("condition", func(t *testing.T) {
+		docs, err := e.Parse(map[string]any{
+			"prod": map[string]any{
+				"if":      `env.APP_ENV == "production"`,
+				"enabled": true,
+			},
+			"dev": map[string]any{
+				"if":      `env("APP_ENV", "") == "development"`,
+				"enabled": true,
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{
+			"prod": map[string]any{"enabled": true},
+		}, docs[0].Map())
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		_, err := e.Parse(map[string]any{"secret": "${env.SECRET}"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not allowed")
+	})
+
+	t.Run("not set", func(t *testing.T) {
+		_, err := e.Parse(map[string]any{"missing": "${env.APP_MISSING}"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not set")
+	})
+
+	t.Run("document variable takes precedence", func(t *testing.T) {
+		docs, err := e.Parse(map[string]any{
+			"env":  map[string]any{"HOME": "/var/lib"},
+			"home": "${env.HOME}",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "/var/lib", docs[0].Map()["home"])
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		_, err := yamlexpr.New(nil).Parse(map[string]any{"home": "${env.HOME}"})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,232 @@
+package yamlexpr
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Explanation describes how the documents of a template were produced.
+type Explanation struct {
+	// Documents are the rendered documents.
+	Documents []Document `json:"-" yaml:"-"`
+	// Values describe the output values, in document and source key order.
+	Values []ValueTrace `json:"values" yaml:"values"`
+	// Omitted describe the blocks omitted by false conditions, ordered by path.
+	Omitted []Omission `json:"omitted,omitempty" yaml:"omitted,omitempty"`
+}
+
+// ValueTrace describes how an output value was produced.
+type ValueTrace struct {
+	// Document is the index of the output document.
+	Document int `json:"document" yaml:"document"`
+	// Path is the path of the value in the document, e.g. "services[1].name".
+	Path string `json:"path" yaml:"path"`
+	// Value is the output value.
+	Value any `json:"value" yaml:"value"`
+	// Source is the template location of the value, e.g. "app.yaml:12".
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+	// Include is the chain of included files the value comes from.
+	Include []string `json:"include,omitempty" yaml:"include,omitempty"`
+	// Steps are the include, condition and iteration events producing the value, outermost first.
+	Steps []TraceEvent `json:"steps,omitempty" yaml:"steps,omitempty"`
+	// Interpolation is the interpolation producing the value, nil for literal values.
+	Interpolation *TraceEvent `json:"interpolation,omitempty" yaml:"interpolation,omitempty"`
+}
+
+// Omission describes a block omitted by a false condition.
+type Omission struct {
+	// Path is the document path of the block, as used in errors.
+	Path string `json:"path" yaml:"path"`
+	// Source is the template location of the block.
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+	// Include is the chain of included files the block comes from.
+	Include []string `json:"include,omitempty" yaml:"include,omitempty"`
+	// Steps are the events scoping the block, outermost first. The last step is the false condition.
+	Steps []TraceEvent `json:"steps" yaml:"steps"`
+}
+
+// Explain renders the template with vars like Execute, and explains how each
+// output value was produced: its source location, the include it came from,
+// the if conditions and the for or matrix iterations scoping it, and the
+// interpolation with the variable values used. Blocks omitted by false
+// conditions are listed with the condition. Secret values are redacted.
+func (t *Template) Explain(vars map[string]any) (*Explanation, error) {
+	e := *t.expr
+	e.recorder = newRecorder()
+
+	docs, err := e.render(t, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &Explanation{
+		Documents: docs,
+	}
+	for i, doc := range docs {
+		ctx := e.recorder.maps[pointer(doc.values)]
+		if ctx == nil {
+			ctx = NewContext(nil)
+		}
+		e.explainValue(x, t.source, i, "", doc.values, doc.order, ctx, nil)
+	}
+	for _, o := range e.recorder.omitted {
+		steps := e.redactEvents(o.ctx.Trace().Events())
+		x.Omitted = append(x.Omitted, Omission{
+			Path:    o.ctx.Path(),
+			Source:  e.traceSource(t.source, o.ctx),
+			Include: o.ctx.IncludeChain(),
+			Steps:   append(steps, e.redactEvent(o.event)),
+		})
+	}
+	sort.SliceStable(x.Omitted, func(i, j int) bool {
+		return x.Omitted[i].Path < x.Omitted[j].Path
+	})
+	return x, nil
+}
+
+// explainValue adds the output values in value to the explanation, in the
+// key order of value. The context is the processing context of value. The interpolation is set
+// when value is a part of an interpolated value.
+func (e *Expr) explainValue(x *Explanation, source *schemaSource, doc int, path string, value any, order *keyOrder, ctx *Context, interp *TraceEvent) {
+	if ev, ok := e.recorder.values[ctx]; ok {
+		interp = &ev
+	}
+
+	switch v := value.(type) {
+	case Document:
+		e.explainValue(x, source, doc, path, v.values, v.order, ctx, interp)
+		return
+	case map[string]any:
+		if len(v) == 0 {
+			break
+		}
+		keys := e.recorder.keys[pointer(v)]
+		base := ctx
+		if mctx, ok := e.recorder.maps[pointer(v)]; ok {
+			base, interp = mctx, nil
+		}
+		for _, k := range order.sort(v) {
+			kctx, ok := keys[k]
+			if !ok {
+				kctx = base.AppendPath(k)
+			}
+			e.explainValue(x, source, doc, joinPath(path, k), v[k], order.value(k), kctx, interp)
+		}
+		return
+	case []any:
+		if len(v) == 0 {
+			break
+		}
+		items, recorded := e.recorder.items[pointer(v)]
+		if recorded {
+			interp = nil
+		}
+		for i, item := range v {
+			var ictx *Context
+			if i < len(items) {
+				ictx = items[i]
+			}
+			if m, ok := item.(map[string]any); ok && ictx == nil {
+				ictx = e.recorder.maps[pointer(m)]
+			}
+			if ictx == nil {
+				ictx = ctx.AppendPath(fmt.Sprintf("[%d]", i))
+			}
+			e.explainValue(x, source, doc, fmt.Sprintf("%s[%d]", path, i), item, order.item(i), ictx, interp)
+		}
+		return
+	}
+
+	vt := ValueTrace{
+		Document: doc,
+		Path:     path,
+		Value:    e.redactValue(value),
+		Source:   e.traceSource(source, ctx),
+		Include:  ctx.IncludeChain(),
+		Steps:    e.redactEvents(ctx.Trace().Events()),
+	}
+	if interp != nil {
+		ev := e.redactEvent(*interp)
+		vt.Interpolation = &ev
+	}
+	x.Values = append(x.Values, vt)
+}
+
+// pathSegmentPattern matches the keys and indexes of a document path.
+var pathSegmentPattern = regexp.MustCompile(`\[\d+\]|[^.\[]+`)
+
+// traceSource returns the template location of the value processed with ctx,
+// e.g. "app.yaml:12". Values from includes are located in the included file.
+func (e *Expr) traceSource(root *schemaSource, ctx *Context) string {
+	filename, node, base := "", (*yaml.Node)(nil), ""
+	if root != nil {
+		filename, node = root.filename, root.node
+	}
+	for t := ctx.Trace(); t != nil; t = t.Parent {
+		if t.Event.Kind == TraceInclude {
+			filename, node, base = t.Event.File, e.recorder.files[t.Event.File], t.Event.Path
+			break
+		}
+	}
+	if node == nil {
+		return ""
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	rel := ctx.Path()[min(len(base), len(ctx.Path())):]
+	line := node.Line
+
+walk:
+	for _, segment := range pathSegmentPattern.FindAllString(rel, -1) {
+		if segment[0] == '[' {
+			i, _ := strconv.Atoi(segment[1 : len(segment)-1])
+			switch {
+			case node.Kind == yaml.MappingNode:
+				// Iteration indexes of for and matrix templates
+				continue
+			case node.Kind == yaml.SequenceNode && i < len(node.Content):
+				node = node.Content[i]
+				line = node.Line
+				continue
+			}
+			break walk
+		}
+		if node.Kind != yaml.MappingNode {
+			break
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == segment {
+				line = node.Content[i].Line
+				node = node.Content[i+1]
+				continue walk
+			}
+		}
+		break
+	}
+
+	return fmt.Sprintf("%s:%d", filename, line)
+}
+
+// redactEvents returns events with secret values redacted.
+func (e *Expr) redactEvents(events []TraceEvent) []TraceEvent {
+	for i, event := range events {
+		events[i] = e.redactEvent(event)
+	}
+	return events
+}
+
+// redactEvent returns an event with secret values redacted.
+func (e *Expr) redactEvent(event TraceEvent) TraceEvent {
+	event.Expression = e.Redact(event.Expression)
+	event.Result = e.redactValue(event.Result)
+	if event.Vars != nil {
+		event.Vars = e.redactValue(event.Vars).(map[string]any)
+	}
+	return event
+}
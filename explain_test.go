@@ -0,0 +1,176 @@
+package yamlexpr_test
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+func TestTemplate_Explain(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.yaml": {Data: []byte(`env: prod
+services:
+  - for: svc in ["api", "worker", "cache"]
+    if: svc != "cache"
+    name: "${svc}-${env}"
+    include: _labels.yaml
+debug:
+  if: env == "dev"
+  level: 1
+`)},
+		"_labels.yaml": {Data: []byte("team: core\nowner: ${svc}\n")},
+	}
+	e := yamlexpr.New(fsys)
+
+	tpl, err := e.LoadTemplate("app.yaml")
+	require.NoError(t, err)
+
+	x, err := tpl.Explain(nil)
+	require.NoError(t, err)
+	require.Len(t, x.Documents, 1)
+
+	values := make(map[string]yamlexpr.ValueTrace)
+	for _, v := range x.Values {
+		values[v.Path] = v
+	}
+	require.Len(t, values, 7)
+
+	t.Run("literal value", func(t *testing.T) {
+		v := values["env"]
+		require.Equal(t, "prod", v.Value)
+		require.Equal(t, "app.yaml:1", v.Source)
+		require.Empty(t, v.Steps)
+		require.Nil(t, v.Interpolation)
+	})
+
+	t.Run("iteration and interpolation", func(t *testing.T) {
+		v := values["services[1].name"]
+		require.Equal(t, "worker-prod", v.Value)
+		require.Equal(t, "app.yaml:5", v.Source)
+
+		require.Len(t, v.Steps, 2)
+		require.Equal(t, yamlexpr.TraceFor, v.Steps[0].Kind)
+		require.Equal(t, 1, v.Steps[0].Index)
+		require.Equal(t, map[string]any{"svc": "worker"}, v.Steps[0].Vars)
+		require.Equal(t, yamlexpr.TraceCondition, v.Steps[1].Kind)
+		require.Equal(t, `svc != "cache"`, v.Steps[1].Expression)
+		require.Equal(t, true, v.Steps[1].Result)
+
+		require.NotNil(t, v.Interpolation)
+		require.Equal(t, "${svc}-${env}", v.Interpolation.Expression)
+		require.Equal(t, map[string]any{"svc": "worker", "env": "prod"}, v.Interpolation.Vars)
+	})
+
+	t.Run("include", func(t *testing.T) {
+		v := values["services[0].owner"]
+		require.Equal(t, "api", v.Value)
+		require.Equal(t, "_labels.yaml:2", v.Source)
+		require.Equal(t, []string{"_labels.yaml"}, v.Include)
+	})
+
+	t.Run("omitted", func(t *testing.T) {
+		paths := make([]string, 0, len(x.Omitted))
+		for _, o := range x.Omitted {
+			paths = append(paths, o.Path)
+			last := o.Steps[len(o.Steps)-1]
+			require.Equal(t, yamlexpr.TraceCondition, last.Kind)
+			require.Equal(t, false, last.Result)
+		}
+		require.ElementsMatch(t, []string{"debug", "services[0][2]"}, paths)
+	})
+}
+
+func TestTemplate_Explain_Secrets(t *testing.T) {
+	secrets := fstest.MapFS{
+		"db/password": {Data: []byte("hunter2")},
+	}
+	e := yamlexpr.New(nil, yamlexpr.WithSecrets(&yamlexpr.FileSecrets{FS: secrets}))
+
+	tpl, err := e.Compile(map[string]any{
+		"password": `${secret("db/password")}`,
+	})
+	require.NoError(t, err)
+
+	x, err := tpl.Explain(nil)
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", x.Documents[0].Map()["password"])
+
+	require.Len(t, x.Values, 1)
+	require.Equal(t, "***", x.Values[0].Value)
+	require.Equal(t, "***", x.Values[0].Interpolation.Result)
+}
+
+func TestExpr_WithTracer(t *testing.T) {
+	var events []yamlexpr.TraceEvent
+	e := yamlexpr.New(nil, yamlexpr.WithTracer(func(_ *yamlexpr.Context, ev yamlexpr.TraceEvent) {
+		events = append(events, ev)
+	}))
+
+	_, err := e.Parse(map[string]any{
+		"items": []any{
+			map[string]any{
+				"for":  "v in [1, 2]",
+				"if":   "v > 1",
+				"name": "${v}",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	kinds := make([]yamlexpr.TraceKind, len(events))
+	for i, ev := range events {
+		kinds[i] = ev.Kind
+	}
+	require.Equal(t, []yamlexpr.TraceKind{
+		yamlexpr.TraceFor,
+		yamlexpr.TraceCondition,
+		yamlexpr.TraceFor,
+		yamlexpr.TraceCondition,
+		yamlexpr.TraceValue,
+	}, kinds)
+	require.Equal(t, "items[0][0].if", events[1].Path)
+	require.Equal(t, false, events[1].Result)
+	require.Equal(t, 2, events[4].Result)
+}
+
+func TestExpr_WithTracer_Secrets(t *testing.T) {
+	secrets := fstest.MapFS{
+		"db": {Data: []byte("hunter2\n")},
+	}
+	var events []yamlexpr.TraceEvent
+	e := yamlexpr.New(nil,
+		yamlexpr.WithSecrets(&yamlexpr.FileSecrets{FS: secrets}),
+		yamlexpr.WithTracer(func(_ *yamlexpr.Context, ev yamlexpr.TraceEvent) {
+			events = append(events, ev)
+		}),
+	)
+
+	docs, err := e.Parse(map[string]any{
+		"password": `${ secret("db") }`,
+		"items": []any{
+			map[string]any{
+				"for":  `pw in [secret("db")]`,
+				"if":   `pw != ""`,
+				"auth": "user:${pw}",
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", docs[0].Map()["password"])
+	require.NotEmpty(t, events)
+
+	for _, ev := range events {
+		require.NotContains(t, fmt.Sprintf("%v %v %v", ev.Expression, ev.Result, ev.Vars), "hunter2", ev.Kind)
+	}
+	require.Contains(t, events, yamlexpr.TraceEvent{
+		Kind:       yamlexpr.TraceValue,
+		Path:       "password",
+		Expression: `${ secret("db") }`,
+		Result:     "***",
+		Vars:       map[string]any{},
+	})
+}
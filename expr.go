@@ -3,22 +3,24 @@ package yamlexpr
 import (
 	"fmt"
 	"io/fs"
+	"maps"
+	"regexp"
 	"strings"
 
-	"github.com/expr-lang/expr"
 	yaml "gopkg.in/yaml.v3"
 
 	"github.com/titpetric/yamlexpr/interpolation"
 	"github.com/titpetric/yamlexpr/stack"
 )
 
-// Document represents a single YAML document after processing.
-type Document map[string]any
-
 // Expr evaluates YAML documents with variable interpolation, conditionals, and composition.
 type Expr struct {
-	fs     fs.FS
-	config *Config
+	fs        fs.FS
+	config    *Config
+	evaluator *interpolation.Evaluator
+	schemas   *schemaCache
+	// recorder records the output contexts for Explain, nil otherwise
+	recorder *recorder
 }
 
 // New creates a new Expr evaluator with the given filesystem for includes.
@@ -28,35 +30,104 @@ func New(rootFS fs.FS, opts ...ConfigOption) *Expr {
 	for _, opt := range opts {
 		opt(config)
 	}
+	evaluator := interpolation.NewEvaluator(config.CompileOptions()...)
+	evaluator.SetUndefined(config.Undefined)
+
 	return &Expr{
-		fs:     rootFS,
-		config: config,
+		fs:        rootFS,
+		config:    config,
+		evaluator: evaluator,
+		schemas:   newSchemaCache(),
 	}
 }
 
-// Parse processes a Document (map[string]any) with expression evaluation.
+// Parse processes a document with expression evaluation.
 // Returns a slice of Documents. For root-level for: directives,
 // may return multiple documents. For regular documents, returns a single-item slice.
-func (e *Expr) Parse(doc Document) ([]Document, error) {
-	// Process the document with root-level keys as variables
-	result, err := e.process(map[string]any(doc), nil)
+// Keys of the returned documents are sorted, see Load for source order.
+func (e *Expr) Parse(doc map[string]any) ([]Document, error) {
+	return e.parse(doc, nil)
+}
+
+// parse processes a Document and validates the result against the configured schema.
+// The source is used to report template locations of schema violations.
+func (e *Expr) parse(doc map[string]any, source *schemaSource) ([]Document, error) {
+	var node *yaml.Node
+	if source != nil {
+		node = source.node
+	}
+	inputs, err := e.parseInputs(doc[e.config.InputsDirective()], node)
 	if err != nil {
 		return nil, err
 	}
 
-	// Convert result (which may be []any or map[string]any) to []Document
+	root := withoutKey(doc, e.config.InputsDirective())
+	return e.render(&Template{doc: root, tree: root, inputs: inputs, source: source}, nil)
+}
+
+// Load loads a YAML file and processes it with expression evaluation.
+// Returns a slice of Documents. For root-level for: or similar directives,
+// may return multiple documents. For regular documents, returns a single-item slice.
+// The filename is resolved relative to the filesystem provided to New().
+// Keys of the returned documents follow the order of the file, see Document.
+func (e *Expr) Load(filename string) ([]Document, error) {
+	node, doc, err := e.readDocument(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// Process and validate, reporting schema violations with template locations
+	docs, err := e.parse(doc, &schemaSource{filename: filename, node: node})
+	if err != nil {
+		return nil, fmt.Errorf("error processing file %s: %w", filename, err)
+	}
+
+	return docs, nil
+}
+
+// readDocument reads and parses a YAML file into a map.
+// The YAML node is returned to report source locations and key order.
+func (e *Expr) readDocument(filename string) (*yaml.Node, map[string]any, error) {
+	data, err := fs.ReadFile(e.fs, filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading file %s: %w", filename, err)
+	}
+	return e.decodeDocument(filename, data)
+}
+
+// decodeDocument parses YAML data into a map.
+func (e *Expr) decodeDocument(filename string, data []byte) (*yaml.Node, map[string]any, error) {
+	// Parse YAML
+	node, parsed, err := e.decodeYAML(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing YAML file %s: %w", filename, err)
+	}
+
+	docMap, ok := parsed.(map[string]any)
+	if !ok {
+		return nil, nil, fmt.Errorf("expected map[string]any from YAML file %s, got %T", filename, parsed)
+	}
+
+	return node, docMap, nil
+}
+
+// toDocuments converts a processing result to a slice of Documents.
+// The result may be a slice (from for/matrix directives) or a single map.
+func toDocuments(result any) ([]Document, error) {
 	var docs []Document
 
+	result, order := detach(result)
+
 	// If result is a slice (from for/matrix directives), convert each item to Document
 	if slice, ok := result.([]any); ok {
-		for _, item := range slice {
+		for i, item := range slice {
 			if docMap, ok := item.(map[string]any); ok {
-				docs = append(docs, Document(docMap))
+				docs = append(docs, Document{values: docMap, order: order.item(i)})
 			}
 		}
 	} else if resultMap, ok := result.(map[string]any); ok {
 		// Single document
-		docs = append(docs, Document(resultMap))
+		docs = append(docs, Document{values: resultMap, order: order})
 	}
 
 	if len(docs) == 0 {
@@ -66,74 +137,148 @@ func (e *Expr) Parse(doc Document) ([]Document, error) {
 	return docs, nil
 }
 
-// Load loads a YAML file and processes it with expression evaluation.
-// Returns a slice of Documents. For root-level for: or similar directives,
-// may return multiple documents. For regular documents, returns a single-item slice.
-// The filename is resolved relative to the filesystem provided to New().
-func (e *Expr) Load(filename string) ([]Document, error) {
-	data, err := fs.ReadFile(e.fs, filename)
+// render processes the document of a template with expression evaluation.
+// Root-level keys in the document are available as variables, with root-level
+// strings interpolated. Supplied vars are validated against the declared
+// inputs and take precedence over root keys.
+// The resulting documents are validated against their schema.
+// Secret values are masked in returned errors.
+// Maps are ordered by the template source node, see Document.
+func (e *Expr) render(t *Template, vars map[string]any) ([]Document, error) {
+	scope, err := e.resolveInputs(t.inputs, vars)
 	if err != nil {
-		return nil, fmt.Errorf("error reading file %s: %w", filename, err)
+		return nil, err
 	}
 
-	// Parse YAML
-	parsed, err := parseYAML(data)
-	if err != nil {
-		return nil, fmt.Errorf("error parsing YAML file %s: %w", filename, err)
+	rootVars := make(map[string]any, len(t.doc))
+	for k, v := range t.doc {
+		rootVars[k] = v
 	}
-
-	// Convert parsed data to Document
-	docMap, ok := parsed.(map[string]any)
-	if !ok {
-		return nil, fmt.Errorf("expected map[string]any from YAML file %s, got %T", filename, parsed)
+	st := stack.NewStack(rootVars)
+	if len(scope) > 0 {
+		st.Push(scope)
 	}
+	e.resolveRootVars(rootVars, st)
 
-	// Wrap with Parse for actual processing
-	docs, err := e.Parse(Document(docMap))
+	var node *yaml.Node
+	if t.source != nil {
+		node = t.source.node
+	}
+	result, err := e.processWithStack(t.tree, node, st)
 	if err != nil {
-		return nil, fmt.Errorf("error processing file %s: %w", filename, err)
+		return nil, e.redactError(err)
 	}
 
+	docs, err := toDocuments(result)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.validate(docs, t.source); err != nil {
+		return nil, e.redactError(err)
+	}
 	return docs, nil
 }
 
-// process processes a YAML document (any) with expression evaluation.
-// Handles for loops, if conditions, includes, and variable interpolation.
-// Root-level keys in the document are available as variables.
-func (e *Expr) process(doc any, rootVars map[string]any) (any, error) {
-	if rootVars == nil {
-		rootVars = make(map[string]any)
-	}
-	if rootMap, ok := doc.(map[string]any); ok {
-		for k, v := range rootMap {
-			rootVars[k] = v
+// resolveRootVars interpolates the root-level string values of a document
+// in place, so a variable referencing another root key, like `${artifact}`,
+// resolves to its interpolated value rather than its template. Values are
+// resolved in dependency order. Values referencing undefined variables, e.g.
+// loop variables, values in a reference cycle, and values referencing such
+// values keep their template.
+func (e *Expr) resolveRootVars(vars map[string]any, st *stack.Stack) {
+	const (
+		visiting = iota + 1
+		kept
+		resolved
+	)
+	state := make(map[string]int, len(vars))
+
+	var resolve func(key string) bool
+	resolve = func(key string) bool {
+		if state[key] != 0 {
+			return state[key] == resolved
+		}
+		state[key] = visiting
+
+		s, ok := vars[key].(string)
+		if !ok || !interpolation.ContainsInterpolation(s) {
+			state[key] = resolved
+			return true
+		}
+		if e.isDirective(key) {
+			state[key] = kept
+			return false
+		}
+
+		for _, segment := range interpolation.Scan(s) {
+			if !segment.Expr {
+				continue
+			}
+			if _, undefined := e.evaluator.UndefinedVariable(segment.Text, st); undefined {
+				state[key] = kept
+				return false
+			}
+			for name := range e.evaluator.Variables(segment.Text, st) {
+				if _, root := vars[name]; root && !resolve(name) {
+					state[key] = kept
+					return false
+				}
+			}
 		}
+
+		val, err := e.evaluator.InterpolateValueWithContext(s, st, key)
+		if err != nil {
+			state[key] = kept
+			return false
+		}
+		vars[key] = val
+		state[key] = resolved
+		return true
+	}
+
+	for key := range vars {
+		resolve(key)
 	}
+}
 
-	return e.processWithStack(doc, stack.NewStack(rootVars))
+// isDirective reports whether key is a directive keyword.
+func (e *Expr) isDirective(key string) bool {
+	switch key {
+	case e.config.IncludeDirective(), e.config.ForDirective(), e.config.MatrixDirective(), e.config.IfDirective(), e.config.InputsDirective():
+		return true
+	}
+	return false
 }
 
 // processWithStack processes a YAML document with a given variable stack.
-func (e *Expr) processWithStack(doc any, st *stack.Stack) (any, error) {
+// The node is the source of the document, nil if it isn't from a file.
+func (e *Expr) processWithStack(doc any, node *yaml.Node, st *stack.Stack) (any, error) {
 	if st == nil {
 		st = stack.New()
 	}
 	ctx := NewContext(&ContextOptions{
-		Stack: st,
+		Stack:     st,
+		Evaluator: e.evaluator,
 	})
-	return e.processWithContext(ctx, doc)
+	return e.processWithContext(ctx, doc, node)
 }
 
 // processWithContext is the internal implementation that handles the processing with context.
-func (e *Expr) processWithContext(ctx *Context, doc any) (any, error) {
+// The node is the source of doc, ordering the keys of processed maps.
+func (e *Expr) processWithContext(ctx *Context, doc any, node *yaml.Node) (any, error) {
 	switch d := doc.(type) {
 	case map[string]any:
-		return e.processMapWithContext(ctx, d)
+		return e.processMapWithContext(ctx, d, node)
 	case []any:
-		return e.processSliceWithContext(ctx, d)
+		return e.processSliceWithContext(ctx, d, node)
 	case string:
 		// Interpolate string values with type preservation (${expr} returns native type, not string)
-		return interpolation.InterpolateValueWithContext(d, ctx.Stack(), ctx.Path())
+		val, err := ctx.Evaluator().InterpolateValueWithContext(d, ctx.Stack(), ctx.Path())
+		if err != nil {
+			return nil, err
+		}
+		e.traceValue(ctx, d, val)
+		return val, nil
 	default:
 		// Return primitives as-is
 		return d, nil
@@ -141,90 +286,114 @@ func (e *Expr) processWithContext(ctx *Context, doc any) (any, error) {
 }
 
 // processMapWithContext processes a map with Context, handling include, for, matrix, and if directives.
-func (e *Expr) processMapWithContext(ctx *Context, m map[string]any) (any, error) {
-	result := make(map[string]any)
+// The processed map keeps the key order of the node, with included keys at the include directive.
+func (e *Expr) processMapWithContext(ctx *Context, m map[string]any, node *yaml.Node) (any, error) {
+	result := newMapping()
+	e.recordMap(ctx, result.values)
+	keys := sourceKeys(node, m)
+
+	// Report misspelled directives in strict mode
+	if e.config.Strict {
+		if err := e.checkDirectiveKeys(m, ctx.Path()); err != nil {
+			return nil, err
+		}
+	}
 
 	// Check for include directive
 	if incl, ok := m[e.config.IncludeDirective()]; ok {
 		if err := e.handleIncludeWithContext(ctx, incl, result); err != nil {
 			return nil, err
 		}
-		// Remove include from processing
-		delete(m, e.config.IncludeDirective())
+		// Remove include from processing, leaving the input unchanged
+		m = withoutKey(m, e.config.IncludeDirective())
 	}
 
 	// Check for matrix directive (before for, same priority)
 	if matrixExpr, ok := m[e.config.MatrixDirective()]; ok {
-		return e.handleMatrixWithContext(ctx, matrixExpr, m)
+		return e.handleMatrixWithContext(ctx, matrixExpr, m, node)
 	}
 
 	// Check for for directive
 	if forExpr, ok := m[e.config.ForDirective()]; ok {
-		return e.handleForWithContext(ctx, forExpr, m)
+		return e.handleForWithContext(ctx, forExpr, m, node)
 	}
 
 	// Check for if directive
 	if ifExpr, ok := m[e.config.IfDirective()]; ok {
 		// Evaluate condition with path context
-		ok, err := evaluateConditionWithPath(ifExpr, ctx.Stack(), ctx.Path()+"."+e.config.IfDirective())
+		ok, err := e.evaluateIf(ctx, ifExpr, ctx.Path()+"."+e.config.IfDirective())
 		if err != nil {
 			return nil, err
 		}
+		ctx = e.traceCondition(ctx, ifExpr, directivePath(ctx, e.config.IfDirective()), ok)
 		if !ok {
 			// Return empty map if condition is false (omit the entire block)
 			return nil, nil
 		}
-		// Remove if from processing
-		delete(m, e.config.IfDirective())
+		// Remove if from processing, leaving the input unchanged
+		m = withoutKey(m, e.config.IfDirective())
 	}
 
 	// Process remaining keys
 	for k, v := range m {
 		childCtx := ctx.AppendPath(k)
-		processed, err := e.processWithContext(childCtx, v)
+		processed, err := e.processWithContext(childCtx, v, sourceValue(node, k))
 		if err != nil {
 			return nil, err
 		}
 		// Only include non-nil results (if: false returns nil)
 		if processed != nil {
-			result[k] = processed
+			result.values[k] = processed
+			e.recordKey(childCtx, result.values, k)
 		}
 	}
 
+	// Order included keys at the include directive
+	result.keys = insertKeys(keys, e.config.IncludeDirective(), result.keys)
 	return result, nil
 }
 
 // processSliceWithContext processes a slice with Context, handling for, matrix, and if directives.
-func (e *Expr) processSliceWithContext(ctx *Context, s []any) (any, error) {
+func (e *Expr) processSliceWithContext(ctx *Context, s []any, node *yaml.Node) (any, error) {
 	result := make([]any, 0, len(s))
+	var itemCtxs []*Context
 
 	for i, item := range s {
 		itemCtx := ctx.AppendPath(fmt.Sprintf("[%d]", i))
+		itemNode := sourceItem(node, i)
 
 		// Check if item is a map with for, matrix, or if directives
 		if m, ok := item.(map[string]any); ok {
 			// Check for matrix directive first (should be evaluated before for and if)
 			if matrixExpr, ok := m[e.config.MatrixDirective()]; ok {
-				processed, err := e.handleMatrixWithContext(itemCtx, matrixExpr, m)
+				processed, err := e.handleMatrixWithContext(itemCtx, matrixExpr, m, itemNode)
 				if err != nil {
 					return nil, err
 				}
 				// handleMatrix returns a slice, extend result
 				if slice, ok := processed.([]any); ok {
 					result = append(result, slice...)
+					// Expanded items are maps, recorded when processed
+					if e.recorder != nil {
+						itemCtxs = append(itemCtxs, make([]*Context, len(slice))...)
+					}
 				}
 				continue
 			}
 
 			// Check for for directive (should be evaluated before if)
 			if forExpr, ok := m[e.config.ForDirective()]; ok {
-				processed, err := e.handleForWithContext(itemCtx, forExpr, m)
+				processed, err := e.handleForWithContext(itemCtx, forExpr, m, itemNode)
 				if err != nil {
 					return nil, err
 				}
 				// handleFor returns a slice, extend result
 				if slice, ok := processed.([]any); ok {
 					result = append(result, slice...)
+					// Expanded items are maps, recorded when processed
+					if e.recorder != nil {
+						itemCtxs = append(itemCtxs, make([]*Context, len(slice))...)
+					}
 				}
 				continue
 			}
@@ -232,33 +401,38 @@ func (e *Expr) processSliceWithContext(ctx *Context, s []any) (any, error) {
 			// If no for or matrix directive, check if directive
 			if ifExpr, ok := m[e.config.IfDirective()]; ok {
 				// Evaluate condition
-				ok, err := evaluateConditionWithPath(ifExpr, ctx.Stack(), itemCtx.Path()+"."+e.config.IfDirective())
+				ok, err := e.evaluateIf(itemCtx, ifExpr, itemCtx.Path()+"."+e.config.IfDirective())
 				if err != nil {
 					return nil, err
 				}
+				itemCtx = e.traceCondition(itemCtx, ifExpr, directivePath(itemCtx, e.config.IfDirective()), ok)
 				if !ok {
 					// Skip this item
 					continue
 				}
-				// Remove if from processing
-				delete(m, e.config.IfDirective())
+				// Remove if from processing, leaving the input unchanged
+				item = withoutKey(m, e.config.IfDirective())
 			}
 		}
 
-		processed, err := e.processWithContext(itemCtx, item)
+		processed, err := e.processWithContext(itemCtx, item, itemNode)
 		if err != nil {
 			return nil, err
 		}
 		if processed != nil {
 			result = append(result, processed)
+			if e.recorder != nil {
+				itemCtxs = append(itemCtxs, itemCtx)
+			}
 		}
 	}
 
+	e.recordItems(itemCtxs, result)
 	return result, nil
 }
 
 // handleIncludeWithContext processes an include directive with Context.
-func (e *Expr) handleIncludeWithContext(ctx *Context, incl any, result map[string]any) error {
+func (e *Expr) handleIncludeWithContext(ctx *Context, incl any, result *mapping) error {
 	// Handle single file
 	if filename, ok := incl.(string); ok {
 		return e.loadAndMergeFileWithContext(ctx, filename, result)
@@ -280,7 +454,7 @@ func (e *Expr) handleIncludeWithContext(ctx *Context, incl any, result map[strin
 }
 
 // loadAndMergeFileWithContext loads a YAML file and merges it into the result with Context.
-func (e *Expr) loadAndMergeFileWithContext(ctx *Context, filename string, result map[string]any) error {
+func (e *Expr) loadAndMergeFileWithContext(ctx *Context, filename string, result *mapping) error {
 	if e.fs == nil {
 		return fmt.Errorf("error including %s: no filesystem configured", filename)
 	}
@@ -291,28 +465,35 @@ func (e *Expr) loadAndMergeFileWithContext(ctx *Context, filename string, result
 	}
 
 	// Parse YAML
-	included, err := parseYAML(data)
+	node, included, err := e.decodeYAML(data)
 	if err != nil {
 		return fmt.Errorf("error parsing YAML file %s: %w", filename, err)
 	}
+	e.recordFile(filename, node)
 
 	// Create new context for included file
-	includedCtx := ctx.WithInclude(filename)
+	includedCtx := e.traceScope(ctx.WithInclude(filename), TraceEvent{
+		Kind:       TraceInclude,
+		Path:       ctx.Path(),
+		File:       filename,
+		Expression: filename,
+	})
 
 	// Process the included document
-	processed, err := e.processWithContext(includedCtx, included)
+	processed, err := e.processWithContext(includedCtx, included, node)
 	if err != nil {
 		return fmt.Errorf("error processing included file %s: %w", filename, err)
 	}
 
 	// Recursively merge into result
 	mergeRecursive(result, processed)
+	e.recordMerge(result.values, processed)
 
 	// Also merge into stack so included variables are available to for/if expressions
-	if processedMap, ok := processed.(map[string]any); ok {
+	if processedMap, ok := mapValues(processed); ok {
 		for k, v := range processedMap {
 			// Update the stack with the processed values from include
-			ctx.Stack().Set(k, v)
+			ctx.Stack().Set(k, plainValue(v))
 		}
 	}
 
@@ -320,43 +501,33 @@ func (e *Expr) loadAndMergeFileWithContext(ctx *Context, filename string, result
 }
 
 // mergeRecursive recursively merges src into dst.
-// For maps: recursively merges nested maps
-// For slices: appends items instead of replacing
+// For maps: recursively merges nested maps, appending new keys in order
+// For slices: overwrites the value
 // For other types: overwrites the value
 func mergeRecursive(dst, src any) {
-	switch srcVal := src.(type) {
-	case map[string]any:
-		// Handle map merging
-		if dstMap, ok := dst.(map[string]any); ok {
-			for k, v := range srcVal {
-				if existingVal, exists := dstMap[k]; exists {
-					// Key exists, merge recursively
-					switch v.(type) {
-					case map[string]any:
-						// Both are maps, merge recursively
-						if _, isMap := existingVal.(map[string]any); isMap {
-							mergeRecursive(existingVal, v)
-						} else {
-							// Destination is not a map, overwrite
-							dstMap[k] = v
-						}
-					case []any:
-						// Source is slice, overwrite existing
-						dstMap[k] = v
-					default:
-						// Scalar value, overwrite
-						dstMap[k] = v
-					}
-				} else {
-					// Key doesn't exist, add it
-					dstMap[k] = v
-				}
+	srcMap, ok := asMapping(src)
+	if !ok {
+		// Primitive type or slice, can't merge
+		return
+	}
+	dstMap, ok := asMapping(dst)
+	if !ok {
+		return
+	}
+
+	for _, k := range srcMap.keys {
+		v := srcMap.values[k]
+		if existingVal, exists := dstMap.values[k]; exists {
+			// Both are maps, merge recursively
+			_, srcIsMap := mapValues(v)
+			_, dstIsMap := mapValues(existingVal)
+			if srcIsMap && dstIsMap {
+				mergeRecursive(existingVal, v)
+				continue
 			}
 		}
-	case []any:
-		// Slices can't be merged at top level (handled in map case)
-	default:
-		// Primitive type, can't merge
+		// Key doesn't exist or isn't a map on both sides, overwrite
+		dstMap.set(k, v)
 	}
 }
 
@@ -368,7 +539,7 @@ func mergeRecursive(dst, src any) {
 //   - Variables can be "_" to omit from the stack
 //
 // m should contain "for" key and template keys.
-func (e *Expr) handleForWithContext(ctx *Context, forExpr any, m map[string]any) (any, error) {
+func (e *Expr) handleForWithContext(ctx *Context, forExpr any, m map[string]any, node *yaml.Node) (any, error) {
 	// Get the collection to iterate over and parse the for expression
 	var items []any
 	var loopVars *ForLoopExpr
@@ -382,6 +553,16 @@ func (e *Expr) handleForWithContext(ctx *Context, forExpr any, m map[string]any)
 			Variables: []string{"item"},
 			Source:    "",
 		}
+	case *compiledFor:
+		// Expression parsed by Compile
+		forExpr = v.expr
+		loopVars = v.loop
+
+		var err error
+		items, err = e.forItems(ctx, loopVars)
+		if err != nil {
+			return nil, err
+		}
 	case string:
 		// Parse as new for expression (e.g., "item in items" or "(idx, item) in items")
 		var err error
@@ -394,25 +575,9 @@ func (e *Expr) handleForWithContext(ctx *Context, forExpr any, m map[string]any)
 			return nil, fmt.Errorf("invalid for expression '%s'%s: %w", v, pathCtx, err)
 		}
 
-		// Resolve the source variable from the stack
-		sourceVal, ok := ctx.Stack().Resolve(loopVars.Source)
-		if !ok {
-			pathCtx := ""
-			if ctx.Path() != "" {
-				pathCtx = fmt.Sprintf(" at %s.for", ctx.Path())
-			}
-			return nil, fmt.Errorf("undefined variable '%s'%s", loopVars.Source, pathCtx)
-		}
-
-		// Convert source to slice
-		if slice, ok := sourceVal.([]any); ok {
-			items = slice
-		} else {
-			pathCtx := ""
-			if ctx.Path() != "" {
-				pathCtx = fmt.Sprintf(" at %s.for", ctx.Path())
-			}
-			return nil, fmt.Errorf("for: variable '%s' must be an array, got %T%s", loopVars.Source, sourceVal, pathCtx)
+		items, err = e.forItems(ctx, loopVars)
+		if err != nil {
+			return nil, err
 		}
 	default:
 		pathCtx := ""
@@ -469,9 +634,19 @@ func (e *Expr) handleForWithContext(ctx *Context, forExpr any, m map[string]any)
 
 		// Create context for this iteration
 		itemCtx := ctx.AppendPath(fmt.Sprintf("[%d]", idx))
+		if e.tracing() {
+			// The scope is cleared when popped, trace a copy
+			itemCtx = e.traceScope(itemCtx, TraceEvent{
+				Kind:       TraceFor,
+				Path:       directivePath(ctx, e.config.ForDirective()),
+				Expression: fmt.Sprint(forExpr),
+				Index:      idx,
+				Vars:       maps.Clone(scope),
+			})
+		}
 
 		// Process template with current item in scope
-		expanded, err := e.processMapWithContext(itemCtx, template)
+		expanded, err := e.processMapWithContext(itemCtx, template, node)
 		if err != nil {
 			ctx.Pop()
 			return nil, err
@@ -487,23 +662,98 @@ func (e *Expr) handleForWithContext(ctx *Context, forExpr any, m map[string]any)
 	return result, nil
 }
 
-// parseYAML parses YAML data into a map[string]any or []any.
-func parseYAML(data []byte) (any, error) {
+// forItems resolves the source of a for expression to the items to iterate over.
+func (e *Expr) forItems(ctx *Context, loopVars *ForLoopExpr) ([]any, error) {
+	// Resolve the source variable or expression
+	sourceVal, err := e.resolveForSource(ctx, loopVars.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert source to slice
+	if slice, ok := sourceVal.([]any); ok {
+		return slice, nil
+	}
+	if stack.IsSlice(sourceVal) {
+		return stack.SliceToAny(sourceVal), nil
+	}
+	pathCtx := ""
+	if ctx.Path() != "" {
+		pathCtx = fmt.Sprintf(" at %s.for", ctx.Path())
+	}
+	return nil, fmt.Errorf("for: variable '%s' must be an array, got %T%s", loopVars.Source, sourceVal, pathCtx)
+}
+
+// forSourcePattern matches for sources that are plain variable paths.
+var forSourcePattern = regexp.MustCompile(`^[\w.]+$`)
+
+// resolveForSource resolves the collection of a for expression.
+// Variable paths are resolved from the stack, other sources are
+// evaluated as expressions with the context expression options.
+func (e *Expr) resolveForSource(ctx *Context, source string) (any, error) {
+	pathCtx := ""
+	if ctx.Path() != "" {
+		pathCtx = fmt.Sprintf(" at %s.for", ctx.Path())
+	}
+
+	if forSourcePattern.MatchString(source) {
+		sourceVal, ok := ctx.Stack().Resolve(source)
+		if ok {
+			return sourceVal, nil
+		}
+
+		if ctx.Evaluator().Undefined() == nil {
+			return nil, fmt.Errorf("undefined variable '%s'%s", source, pathCtx)
+		}
+		sourceVal, err := ctx.Evaluator().UndefinedValue(interpolation.Undefined{
+			Name: source,
+			Expr: source,
+			Raw:  source,
+			Path: ctx.Path() + ".for",
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Null and empty values iterate over nothing
+		if sourceVal == nil || sourceVal == "" {
+			return []any{}, nil
+		}
+		return sourceVal, nil
+	}
+
+	program, err := ctx.Evaluator().CompileWithContext(source, ctx.Stack(), ctx.Path()+".for")
+	if err != nil {
+		return nil, fmt.Errorf("error compiling for source '%s'%s: %w", source, pathCtx, err)
+	}
+	sourceVal, err := program.Run()
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating for source '%s'%s: %w", source, pathCtx, err)
+	}
+	return sourceVal, nil
+}
+
+// decodeYAML parses YAML data, returning the YAML node and the decoded value.
+func (e *Expr) decodeYAML(data []byte) (*yaml.Node, any, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, nil, fmt.Errorf("error parsing YAML: %w", err)
+	}
+
 	var result any
-	if err := yaml.Unmarshal(data, &result); err != nil {
-		return nil, fmt.Errorf("error parsing YAML: %w", err)
+	if err := node.Decode(&result); err != nil {
+		return nil, nil, fmt.Errorf("error parsing YAML: %w", err)
 	}
-	return result, nil
+	return &node, result, nil
 }
 
-// evaluateConditionWithPath evaluates an if condition with path context for error messages.
+// evaluateCondition evaluates an if condition using the evaluator to compile expressions.
 // Supports:
 // - Boolean values: true/false
 // - Interpolated expressions: "${item.active}"
 // - Direct variable paths: item.active (converted to expressions via go-expr)
 // - Complex expressions: item.status == 'active', item.count > 5, etc.
 // Returns errors with variable context and path if referenced variables don't exist.
-func evaluateConditionWithPath(condition any, st *stack.Stack, path string) (bool, error) {
+func evaluateCondition(ev *interpolation.Evaluator, condition any, st *stack.Stack, path string) (bool, error) {
 	switch v := condition.(type) {
 	case bool:
 		return v, nil
@@ -518,10 +768,14 @@ func evaluateConditionWithPath(condition any, st *stack.Stack, path string) (boo
 
 		// Handle interpolated expressions like "${item.active}"
 		if strings.Contains(v, "${") {
-			str, err := interpolation.InterpolateStringWithContext(st, v, path)
+			val, err := ev.InterpolateConditionWithContext(v, st, path)
 			if err != nil {
 				return false, err
 			}
+			if val == nil {
+				return false, nil
+			}
+			str := fmt.Sprintf("%v", val)
 			// After interpolation, try to parse as boolean
 			switch str {
 			case "true", "1", "yes":
@@ -536,8 +790,7 @@ func evaluateConditionWithPath(condition any, st *stack.Stack, path string) (boo
 		}
 
 		// Use go-expr to evaluate the expression
-		env := st.All()
-		program, err := expr.Compile(v, expr.Env(env))
+		program, err := ev.CompileWithContext(v, st, path)
 		if err != nil {
 			pathCtx := ""
 			if path != "" {
@@ -546,7 +799,7 @@ func evaluateConditionWithPath(condition any, st *stack.Stack, path string) (boo
 			return false, fmt.Errorf("error compiling expression '%s'%s: %w", v, pathCtx, err)
 		}
 
-		result, err := expr.Run(program, env)
+		result, err := program.Run()
 		if err != nil {
 			pathCtx := ""
 			if path != "" {
@@ -30,14 +30,14 @@ func TestDebugForWithIf(t *testing.T) {
 	interp := interpolateStringHelper("${item.active}", st)
 	t.Logf("Interpolated '${item.active}' -> '%s'", interp)
 
-	cond, err := evaluateConditionWithPath("${item.active}", st, "")
+	cond, err := evaluateCondition(e.evaluator, "${item.active}", st, "")
 	require.NoError(t, err)
 	t.Logf("Condition '${item.active}' evaluated to: %v", cond)
 	st.Pop()
 
 	// Now test the full process
-	docs, err := e.Parse(Document(input))
+	docs, err := e.Parse(input)
 	require.NoError(t, err)
 	require.Len(t, docs, 1)
-	t.Logf("Result: %v", map[string]any(docs[0]))
+	t.Logf("Result: %v", docs[0].Map())
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/titpetric/yamlexpr/interpolation"
 	"github.com/titpetric/yamlexpr/stack"
 )
 
@@ -63,10 +64,10 @@ func TestExpr_ProcessIfConditions(t *testing.T) {
 	e := New(nil)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			docs, err := e.Parse(Document(tt.input))
+			docs, err := e.Parse(tt.input)
 			require.NoError(t, err)
 			require.Len(t, docs, 1)
-			result := map[string]any(docs[0])
+			result := docs[0].Map()
 			require.Equal(t, tt.expected, result)
 		})
 	}
@@ -157,10 +158,10 @@ func TestExpr_ProcessForLoops(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			inputDoc, ok := tt.input.(map[string]any)
 			require.True(t, ok, "input must be map[string]any")
-			docs, err := e.Parse(Document(inputDoc))
+			docs, err := e.Parse(inputDoc)
 			require.NoError(t, err)
 			require.Len(t, docs, 1)
-			result := map[string]any(docs[0])
+			result := docs[0].Map()
 			require.Equal(t, tt.expected, result)
 		})
 	}
@@ -202,10 +203,10 @@ func TestExpr_ProcessForWithIf(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			inputDoc, ok := tt.input.(map[string]any)
 			require.True(t, ok, "input must be map[string]any")
-			docs, err := e.Parse(Document(inputDoc))
+			docs, err := e.Parse(inputDoc)
 			require.NoError(t, err)
 			require.Len(t, docs, 1)
-			result := map[string]any(docs[0])
+			result := docs[0].Map()
 			require.Equal(t, tt.expected, result)
 		})
 	}
@@ -299,11 +300,24 @@ func TestExpr_EvaluateCondition(t *testing.T) {
 			st:        stack.NewStack(map[string]any{"status": "inactive"}),
 			expected:  false,
 		},
+		{
+			name:      "pipe-filter",
+			condition: "(status | upper) == 'ACTIVE'",
+			st:        stack.NewStack(map[string]any{"status": "active"}),
+			expected:  true,
+		},
+		{
+			name:      "pipe-filter-interpolated",
+			condition: "${ status | trim | upper } == ACTIVE",
+			st:        stack.NewStack(map[string]any{"status": " active "}),
+			expected:  true,
+		},
 	}
 
+	ev := interpolation.NewEvaluator()
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := evaluateConditionWithPath(tt.condition, tt.st, "")
+			result, err := evaluateCondition(ev, tt.condition, tt.st, "")
 			require.NoError(t, err)
 			require.Equal(t, tt.expected, result)
 		})
@@ -330,7 +344,7 @@ func TestProcessMapWithContext_EdgeCases(t *testing.T) {
 			checkFn: func(t *testing.T, docs []Document, err error) {
 				require.NoError(t, err)
 				require.Len(t, docs, 1)
-				require.NotNil(t, docs[0]["config"])
+				require.NotNil(t, docs[0].Map()["config"])
 			},
 		},
 		{
@@ -347,7 +361,7 @@ func TestProcessMapWithContext_EdgeCases(t *testing.T) {
 				require.NoError(t, err)
 				require.Len(t, docs, 1)
 				// Verify if condition was processed
-				require.NotNil(t, docs[0]["settings"])
+				require.NotNil(t, docs[0].Map()["settings"])
 			},
 		},
 		{
@@ -360,7 +374,7 @@ func TestProcessMapWithContext_EdgeCases(t *testing.T) {
 			checkFn: func(t *testing.T, docs []Document, err error) {
 				require.NoError(t, err)
 				require.Len(t, docs, 1)
-				require.NotNil(t, docs[0]["config"])
+				require.NotNil(t, docs[0].Map()["config"])
 			},
 		},
 	}
@@ -394,7 +408,7 @@ func TestProcessSliceWithContext_EdgeCases(t *testing.T) {
 			checkFn: func(t *testing.T, docs []Document, err error) {
 				require.NoError(t, err)
 				require.Len(t, docs, 1)
-				require.NotNil(t, docs[0]["hosts"])
+				require.NotNil(t, docs[0].Map()["hosts"])
 			},
 		},
 		{
@@ -411,7 +425,7 @@ func TestProcessSliceWithContext_EdgeCases(t *testing.T) {
 			checkFn: func(t *testing.T, docs []Document, err error) {
 				require.NoError(t, err)
 				require.Len(t, docs, 1)
-				require.NotNil(t, docs[0]["items"])
+				require.NotNil(t, docs[0].Map()["items"])
 			},
 		},
 		{
@@ -426,7 +440,7 @@ func TestProcessSliceWithContext_EdgeCases(t *testing.T) {
 			checkFn: func(t *testing.T, docs []Document, err error) {
 				require.NoError(t, err)
 				require.Len(t, docs, 1)
-				require.NotNil(t, docs[0]["data"])
+				require.NotNil(t, docs[0].Map()["data"])
 			},
 		},
 		{
@@ -442,7 +456,7 @@ func TestProcessSliceWithContext_EdgeCases(t *testing.T) {
 			checkFn: func(t *testing.T, docs []Document, err error) {
 				require.NoError(t, err)
 				require.Len(t, docs, 1)
-				require.NotNil(t, docs[0]["items"])
+				require.NotNil(t, docs[0].Map()["items"])
 			},
 		},
 		{
@@ -465,7 +479,7 @@ func TestProcessSliceWithContext_EdgeCases(t *testing.T) {
 			checkFn: func(t *testing.T, docs []Document, err error) {
 				require.NoError(t, err)
 				require.Len(t, docs, 1)
-				require.NotNil(t, docs[0]["items"])
+				require.NotNil(t, docs[0].Map()["items"])
 			},
 		},
 	}
@@ -1,7 +1,9 @@
 package yamlexpr_test
 
 import (
+	"sync"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/require"
 
@@ -16,7 +18,7 @@ func TestNew(t *testing.T) {
 func TestExpr_Parse(t *testing.T) {
 	e := yamlexpr.New(nil)
 
-	doc := yamlexpr.Document{
+	doc := map[string]any{
 		"name":  "${user.name}",
 		"items": []any{"a", "b"},
 		"user": map[string]any{
@@ -24,7 +26,7 @@ func TestExpr_Parse(t *testing.T) {
 		},
 	}
 
-	want := yamlexpr.Document{
+	want := map[string]any{
 		"name":  "John",
 		"items": []any{"a", "b"},
 		"user": map[string]any{
@@ -36,5 +38,75 @@ func TestExpr_Parse(t *testing.T) {
 
 	require.NoError(t, err)
 	require.Len(t, docs, 1)
-	require.Equal(t, want, docs[0])
+	require.Equal(t, want, docs[0].Map())
+}
+
+// sharedTemplate returns a document using include, if, for and matrix directives.
+func sharedTemplate() map[string]any {
+	return map[string]any{
+		"include": "_base.yaml",
+		"debug": map[string]any{
+			"if":    "env == 'dev'",
+			"level": "verbose",
+		},
+		"services": []any{
+			map[string]any{
+				"if":   true,
+				"name": "api",
+			},
+			map[string]any{
+				"for":  "item in items",
+				"name": "${item}",
+			},
+			map[string]any{
+				"matrix": map[string]any{"os": []any{"linux", "darwin"}},
+				"name":   "build-${os}",
+			},
+		},
+	}
+}
+
+func TestExpr_Parse_Immutable(t *testing.T) {
+	fs := fstest.MapFS{
+		"_base.yaml": {Data: []byte("env: dev\nitems: [a, b]\n")},
+	}
+	e := yamlexpr.New(fs)
+
+	doc := sharedTemplate()
+
+	first, err := e.Parse(doc)
+	require.NoError(t, err)
+	require.Equal(t, sharedTemplate(), doc)
+
+	second, err := e.Parse(doc)
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+	require.Equal(t, "verbose", second[0].Map()["debug"].(map[string]any)["level"])
+}
+
+func TestExpr_Parse_Concurrent(t *testing.T) {
+	fs := fstest.MapFS{
+		"_base.yaml": {Data: []byte("env: dev\nitems: [a, b]\n")},
+	}
+	e := yamlexpr.New(fs)
+
+	doc := sharedTemplate()
+	want, err := e.Parse(sharedTemplate())
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				docs, err := e.Parse(doc)
+				require.NoError(t, err)
+				require.Equal(t, want, docs)
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, sharedTemplate(), doc)
 }
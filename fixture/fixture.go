@@ -0,0 +1,137 @@
+// Package fixture loads and runs yamlexpr fixture files.
+//
+// A fixture file holds frontmatter, an input template and the expected
+// output documents, separated by `---`:
+//
+//	---
+//	title: "For loop"
+//	description: "Expands a template for each item."
+//	category: "basic"
+//	tags: ["for"]
+//	---
+//	for: name in ["a", "b"]
+//	name: ${name}
+//	---
+//	name: a
+//	---
+//	name: b
+//
+// Includes in the input are resolved relative to the fixture file.
+// Files with names starting with `_` are included files, not fixtures.
+package fixture
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/titpetric/yamlexpr/frontmatter"
+)
+
+// Fixture is a test case loaded from a fixture file.
+type Fixture struct {
+	// Path is the fixture file path.
+	Path string
+	// Name identifies the fixture, the path relative to the loaded directory without extension.
+	Name string
+
+	// Title is the frontmatter title.
+	Title string
+	// Description is the frontmatter description.
+	Description string
+	// Category is the frontmatter category.
+	Category string
+	// Tags are the frontmatter tags.
+	Tags []string
+	// Frontmatter holds all frontmatter fields.
+	Frontmatter map[string]any
+
+	// Input is the template source.
+	Input string
+	// Expected are the sources of the expected output documents.
+	Expected []string
+}
+
+// Load loads a fixture file.
+func Load(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading fixture %s: %w", path, err)
+	}
+
+	doc, err := frontmatter.ParseDocument(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing fixture %s: %w", path, err)
+	}
+	if len(doc.Sections) == 0 {
+		return nil, fmt.Errorf("error parsing fixture %s: missing input section", path)
+	}
+
+	f := &Fixture{
+		Path:        path,
+		Name:        strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Title:       doc.GetFrontmatterFieldWithDefault("title", ""),
+		Description: doc.GetFrontmatterFieldWithDefault("description", ""),
+		Category:    doc.GetFrontmatterFieldWithDefault("category", ""),
+		Frontmatter: doc.Frontmatter,
+		Input:       doc.Sections[0],
+		Expected:    doc.Sections[1:],
+	}
+	if tags, ok := doc.Frontmatter["tags"].([]any); ok {
+		for _, tag := range tags {
+			if s, ok := tag.(string); ok {
+				f.Tags = append(f.Tags, s)
+			}
+		}
+	}
+	return f, nil
+}
+
+// LoadDir loads the fixtures in a directory and its subdirectories, ordered by path.
+func LoadDir(dir string) ([]*Fixture, error) {
+	paths, err := Find(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := make([]*Fixture, 0, len(paths))
+	for _, path := range paths {
+		f, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			f.Name = filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+		}
+		fixtures = append(fixtures, f)
+	}
+	return fixtures, nil
+}
+
+// Find returns the paths of fixture files in a directory and its subdirectories, sorted.
+// Fixture files have a .yaml or .yml extension, and don't start with `_`.
+func Find(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), "_") {
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml":
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error finding fixtures in %s: %w", dir, err)
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
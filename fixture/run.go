@@ -0,0 +1,128 @@
+package fixture
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pmezard/go-difflib/difflib"
+	"gopkg.in/yaml.v3"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+// Result is the outcome of running a fixture.
+type Result struct {
+	// Fixture is the fixture that was run.
+	Fixture *Fixture
+	// Actual are the processed documents, encoded as YAML in source key order.
+	Actual []string
+	// Err is the error processing the input, or decoding the expected documents.
+	Err error
+	// Diff is a unified diff between the expected and actual documents,
+	// empty if they are equal.
+	Diff string
+}
+
+// Passed reports whether the actual documents match the expected documents.
+func (r *Result) Passed() bool {
+	return r.Err == nil && r.Diff == ""
+}
+
+// Run processes the fixture input and compares the resulting documents with
+// the expected documents. Documents are compared by value, so formatting,
+// quoting and key order don't need to match.
+func (f *Fixture) Run(opts ...yamlexpr.ConfigOption) *Result {
+	r := &Result{Fixture: f}
+
+	r.Actual, r.Err = f.process(opts...)
+	if r.Err != nil {
+		return r
+	}
+
+	expected, err := decodeAll(f.Expected)
+	if err != nil {
+		r.Err = fmt.Errorf("error parsing expected output of %s: %w", f.Path, err)
+		return r
+	}
+	actual, err := decodeAll(r.Actual)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+
+	if !cmp.Equal(expected, actual) {
+		r.Diff = diff(expected, actual)
+	}
+	return r
+}
+
+// process processes the fixture input, returning the resulting documents.
+func (f *Fixture) process(opts ...yamlexpr.ConfigOption) ([]string, error) {
+	e := yamlexpr.New(os.DirFS(filepath.Dir(f.Path)), opts...)
+
+	tpl, err := e.CompileYAML(filepath.Base(f.Path), []byte(f.Input))
+	if err != nil {
+		return nil, err
+	}
+	docs, err := tpl.Execute(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		out, err := e.Marshal([]yamlexpr.Document{doc})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, strings.TrimSuffix(string(out), "\n"))
+	}
+	return result, nil
+}
+
+// decodeAll decodes YAML documents.
+func decodeAll(docs []string) ([]any, error) {
+	result := make([]any, 0, len(docs))
+	for i, doc := range docs {
+		var v any
+		if err := yaml.Unmarshal([]byte(doc), &v); err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+// diff returns a unified diff of documents, encoded with sorted keys.
+// Values that differ only by type (e.g. 1 and 1.0) are reported with cmp.Diff.
+func diff(expected, actual []any) string {
+	result, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(canonical(expected)),
+		B:        difflib.SplitLines(canonical(actual)),
+		FromFile: "expected",
+		ToFile:   "actual",
+		Context:  3,
+	})
+	if result == "" {
+		return cmp.Diff(expected, actual)
+	}
+	return result
+}
+
+// canonical encodes documents as a YAML stream with sorted keys.
+func canonical(docs []any) string {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			fmt.Fprintf(&buf, "# %v\n", err)
+		}
+	}
+	enc.Close()
+	return buf.String()
+}
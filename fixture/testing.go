@@ -0,0 +1,50 @@
+package fixture
+
+import (
+	"testing"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+// Test runs the fixtures in a directory as subtests named by fixture name.
+// Options configure the Expr processing each fixture.
+//
+// With update, the expected sections of fixtures with differing output are
+// rewritten with the actual documents. Fixtures that fail to process still
+// fail the test. The package registers no flags; callers usually pass an
+// -update flag declared in their test file.
+//
+// Example:
+//
+//	var update = flag.Bool("update", false, "update fixture files")
+//
+//	func TestFixtures(t *testing.T) {
+//		fixture.Test(t, "testdata/fixtures", *update)
+//	}
+func Test(t *testing.T, dir string, update bool, opts ...yamlexpr.ConfigOption) {
+	t.Helper()
+
+	fixtures, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range fixtures {
+		t.Run(f.Name, func(t *testing.T) {
+			r := f.Run(opts...)
+			if r.Err != nil {
+				t.Fatalf("%s: %v", f.Path, r.Err)
+			}
+			if r.Diff != "" && update {
+				if err := f.Update(r.Actual); err != nil {
+					t.Fatal(err)
+				}
+				t.Logf("updated %s", f.Path)
+				return
+			}
+			if r.Diff != "" {
+				t.Errorf("%s: output mismatch:\n%s", f.Path, r.Diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package fixture
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// separator separates the frontmatter, input and expected sections of a fixture file.
+const separator = "---"
+
+// Update rewrites the expected sections of the fixture file with docs.
+// The frontmatter and input section are kept verbatim.
+func (f *Fixture) Update(docs []string) error {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return fmt.Errorf("error reading fixture %s: %w", f.Path, err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(head(string(data)))
+	for i, doc := range docs {
+		if i > 0 {
+			sb.WriteString(separator + "\n")
+		}
+		sb.WriteString(strings.TrimRight(doc, "\n") + "\n")
+	}
+
+	if err := os.WriteFile(f.Path, []byte(sb.String()), 0o644); err != nil {
+		return fmt.Errorf("error updating fixture %s: %w", f.Path, err)
+	}
+
+	f.Expected = make([]string, len(docs))
+	for i, doc := range docs {
+		f.Expected[i] = strings.TrimSpace(doc)
+	}
+	return nil
+}
+
+// head returns the frontmatter and input section of a fixture file, up to and
+// including the separator starting the expected sections. A separator is
+// added if the file has no expected sections.
+func head(content string) string {
+	lines := strings.SplitAfter(content, "\n")
+
+	// A leading separator opens the frontmatter, the next ones close the
+	// frontmatter and the input section.
+	separators := 0
+	started := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !started && trimmed == "" {
+			continue
+		}
+		if trimmed != separator {
+			started = true
+			continue
+		}
+		if !started {
+			started = true
+			continue
+		}
+
+		separators++
+		if separators == 2 {
+			return strings.Join(lines[:i+1], "")
+		}
+	}
+
+	content = strings.TrimRight(content, "\n") + "\n"
+	return content + separator + "\n"
+}
@@ -0,0 +1,63 @@
+package fixture
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixture_Update(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		docs    []string
+		want    string
+	}{
+		{
+			name:    "replaces expected sections",
+			content: "---\ntitle: \"Loop\"  # kept\n---\n# input comment\nname: ${'a'}\n---\nname: b\n---\nname: c\n",
+			docs:    []string{"name: a"},
+			want:    "---\ntitle: \"Loop\"  # kept\n---\n# input comment\nname: ${'a'}\n---\nname: a\n",
+		},
+		{
+			name:    "multiple documents",
+			content: "\n---\ntitle: Loop\n---\nfor: v in [1, 2]\nv: ${v}\n---\n",
+			docs:    []string{"v: 1\n", "v: 2\n"},
+			want:    "\n---\ntitle: Loop\n---\nfor: v in [1, 2]\nv: ${v}\n---\nv: 1\n---\nv: 2\n",
+		},
+		{
+			name:    "missing expected section",
+			content: "---\ntitle: Loop\n---\nname: a",
+			docs:    []string{"name: a"},
+			want:    "---\ntitle: Loop\n---\nname: a\n---\nname: a\n",
+		},
+		{
+			name:    "without leading separator",
+			content: "title: Loop\n---\nname: a\n---\nname: b\n",
+			docs:    []string{"name: a"},
+			want:    "title: Loop\n---\nname: a\n---\nname: a\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "fixture.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tc.content), 0o644))
+
+			f, err := Load(path)
+			require.NoError(t, err)
+			require.NoError(t, f.Update(tc.docs))
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, string(data))
+
+			reloaded, err := Load(path)
+			require.NoError(t, err)
+			require.Equal(t, f.Expected, reloaded.Expected)
+			require.True(t, reloaded.Run().Passed())
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package yamlexpr_test
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/titpetric/yamlexpr/fixture"
+)
+
+// update is the -update test flag, rewriting the expected sections of failing fixtures.
+var update = flag.Bool("update", false, "update the expected sections of failing fixture files")
+
+func TestFixtures(t *testing.T) {
+	fixture.Test(t, "testdata/fixtures", *update)
+	fixture.Test(t, "testdata/fixtures-by-feature", *update)
+}
@@ -5,6 +5,6 @@ type ForLoopExpr struct {
 	// Variables is a list of variable names to bind. Can include "_" to omit.
 	Variables []string
 
-	// Source is the name of the variable to iterate over.
+	// Source is the variable path or expression to iterate over.
 	Source string
 }
@@ -0,0 +1,201 @@
+package funcs
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// isEmpty returns true for nil, zero numbers, false, empty strings and empty collections.
+func isEmpty(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return rv.IsZero()
+	}
+}
+
+// defaultValue returns the value, or the default if the value is empty.
+func defaultValue(params ...any) (any, error) {
+	if err := arity("default", params, 2, 2); err != nil {
+		return nil, err
+	}
+	if isEmpty(params[0]) {
+		return params[1], nil
+	}
+	return params[0], nil
+}
+
+// coalesce returns the first non-empty value, or nil.
+func coalesce(params ...any) (any, error) {
+	for _, param := range params {
+		if !isEmpty(param) {
+			return param, nil
+		}
+	}
+	return nil, nil
+}
+
+// empty returns true if the value is empty.
+func empty(params ...any) (any, error) {
+	if err := arity("empty", params, 1, 1); err != nil {
+		return nil, err
+	}
+	return isEmpty(params[0]), nil
+}
+
+// dict creates a map from key/value pairs.
+func dict(params ...any) (any, error) {
+	if len(params)%2 != 0 {
+		return nil, fmt.Errorf("dict: expected key/value pairs, got %d arguments", len(params))
+	}
+	result := make(map[string]any, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		result[toString(params[i])] = params[i+1]
+	}
+	return result, nil
+}
+
+// list creates a list from the arguments.
+func list(params ...any) (any, error) {
+	result := make([]any, len(params))
+	copy(result, params)
+	return result, nil
+}
+
+// merge deep merges maps into a new map, later maps take precedence.
+func merge(params ...any) (any, error) {
+	if err := arity("merge", params, 1, -1); err != nil {
+		return nil, err
+	}
+	result := make(map[string]any)
+	for _, param := range params {
+		m, err := toMap("merge", param)
+		if err != nil {
+			return nil, err
+		}
+		mergeInto(result, m)
+	}
+	return result, nil
+}
+
+// mergeInto deep merges src into dst, copying nested maps so src is never modified.
+func mergeInto(dst, src map[string]any) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]any)
+		dstMap, dstIsMap := dst[k].(map[string]any)
+		switch {
+		case srcIsMap && dstIsMap:
+			mergeInto(dstMap, srcMap)
+		case srcIsMap:
+			copied := make(map[string]any, len(srcMap))
+			mergeInto(copied, srcMap)
+			dst[k] = copied
+		default:
+			dst[k] = v
+		}
+	}
+}
+
+// pick returns a new map with only the given keys.
+func pick(params ...any) (any, error) {
+	if err := arity("pick", params, 1, -1); err != nil {
+		return nil, err
+	}
+	m, err := toMap("pick", params[0])
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]any)
+	for _, key := range params[1:] {
+		k := toString(key)
+		if v, ok := m[k]; ok {
+			result[k] = v
+		}
+	}
+	return result, nil
+}
+
+// omit returns a new map without the given keys.
+func omit(params ...any) (any, error) {
+	if err := arity("omit", params, 1, -1); err != nil {
+		return nil, err
+	}
+	m, err := toMap("omit", params[0])
+	if err != nil {
+		return nil, err
+	}
+	omitted := make(map[string]bool, len(params)-1)
+	for _, key := range params[1:] {
+		omitted[toString(key)] = true
+	}
+	result := make(map[string]any, len(m))
+	for k, v := range m {
+		if !omitted[k] {
+			result[k] = v
+		}
+	}
+	return result, nil
+}
+
+// sortedKeys returns the keys of a map in sorted order.
+func sortedKeys(m map[string]any) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// keys returns the sorted keys of a map.
+func keys(params ...any) (any, error) {
+	if err := arity("keys", params, 1, 1); err != nil {
+		return nil, err
+	}
+	m, err := toMap("keys", params[0])
+	if err != nil {
+		return nil, err
+	}
+	result := make([]any, 0, len(m))
+	for _, k := range sortedKeys(m) {
+		result = append(result, k)
+	}
+	return result, nil
+}
+
+// values returns the values of a map, ordered by key.
+func values(params ...any) (any, error) {
+	if err := arity("values", params, 1, 1); err != nil {
+		return nil, err
+	}
+	m, err := toMap("values", params[0])
+	if err != nil {
+		return nil, err
+	}
+	result := make([]any, 0, len(m))
+	for _, k := range sortedKeys(m) {
+		result = append(result, m[k])
+	}
+	return result, nil
+}
+
+// hasKey returns true if the map contains the key.
+func hasKey(params ...any) (any, error) {
+	if err := arity("hasKey", params, 2, 2); err != nil {
+		return nil, err
+	}
+	m, err := toMap("hasKey", params[0])
+	if err != nil {
+		return nil, err
+	}
+	_, ok := m[toString(params[1])]
+	return ok, nil
+}
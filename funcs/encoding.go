@@ -0,0 +1,182 @@
+package funcs
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// toJson encodes a value as JSON.
+func toJson(params ...any) (any, error) {
+	if err := arity("toJson", params, 1, 1); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(params[0])
+	if err != nil {
+		return nil, fmt.Errorf("toJson: %w", err)
+	}
+	return string(data), nil
+}
+
+// fromJson decodes a JSON string.
+func fromJson(params ...any) (any, error) {
+	if err := arity("fromJson", params, 1, 1); err != nil {
+		return nil, err
+	}
+	var result any
+	if err := json.Unmarshal([]byte(toString(params[0])), &result); err != nil {
+		return nil, fmt.Errorf("fromJson: %w", err)
+	}
+	return result, nil
+}
+
+// toYaml encodes a value as YAML, without the trailing newline.
+func toYaml(params ...any) (any, error) {
+	if err := arity("toYaml", params, 1, 1); err != nil {
+		return nil, err
+	}
+	data, err := yaml.Marshal(params[0])
+	if err != nil {
+		return nil, fmt.Errorf("toYaml: %w", err)
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// fromYaml decodes a YAML string.
+func fromYaml(params ...any) (any, error) {
+	if err := arity("fromYaml", params, 1, 1); err != nil {
+		return nil, err
+	}
+	var result any
+	if err := yaml.Unmarshal([]byte(toString(params[0])), &result); err != nil {
+		return nil, fmt.Errorf("fromYaml: %w", err)
+	}
+	return result, nil
+}
+
+// b64enc encodes a string with standard base64 encoding.
+func b64enc(params ...any) (any, error) {
+	if err := arity("b64enc", params, 1, 1); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.EncodeToString([]byte(toString(params[0]))), nil
+}
+
+// b64dec decodes a standard base64 encoded string.
+func b64dec(params ...any) (any, error) {
+	if err := arity("b64dec", params, 1, 1); err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(toString(params[0]))
+	if err != nil {
+		return nil, fmt.Errorf("b64dec: %w", err)
+	}
+	return string(data), nil
+}
+
+// sha256sum returns the hex encoded SHA-256 checksum of a string.
+func sha256sum(params ...any) (any, error) {
+	if err := arity("sha256sum", params, 1, 1); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(toString(params[0])))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// sha1sum returns the hex encoded SHA-1 checksum of a string.
+func sha1sum(params ...any) (any, error) {
+	if err := arity("sha1sum", params, 1, 1); err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum([]byte(toString(params[0])))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// md5sum returns the hex encoded MD5 checksum of a string.
+func md5sum(params ...any) (any, error) {
+	if err := arity("md5sum", params, 1, 1); err != nil {
+		return nil, err
+	}
+	sum := md5.Sum([]byte(toString(params[0])))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// uuidNamespaces are the predefined namespaces of RFC 4122.
+var uuidNamespaces = map[string]string{
+	"dns":  "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+	"url":  "6ba7b811-9dad-11d1-80b4-00c04fd430c8",
+	"oid":  "6ba7b812-9dad-11d1-80b4-00c04fd430c8",
+	"x500": "6ba7b814-9dad-11d1-80b4-00c04fd430c8",
+}
+
+// uuidv5 returns a deterministic name based UUID (RFC 4122, version 5).
+// The namespace is a UUID or one of "dns", "url", "oid", "x500".
+func uuidv5(params ...any) (any, error) {
+	if err := arity("uuidv5", params, 2, 2); err != nil {
+		return nil, err
+	}
+	namespace := toString(params[0])
+	if ns, ok := uuidNamespaces[namespace]; ok {
+		namespace = ns
+	}
+	nsBytes, err := hex.DecodeString(strings.ReplaceAll(namespace, "-", ""))
+	if err != nil || len(nsBytes) != 16 {
+		return nil, fmt.Errorf("uuidv5: invalid namespace %q", toString(params[0]))
+	}
+
+	h := sha1.New()
+	h.Write(nsBytes)
+	h.Write([]byte(toString(params[1])))
+	sum := h.Sum(nil)[:16]
+
+	// Set version (5) and variant (RFC 4122) bits
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16]), nil
+}
+
+// regexMatch returns true if the string matches the regular expression.
+func regexMatch(params ...any) (any, error) {
+	if err := arity("regexMatch", params, 2, 2); err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(toString(params[1]))
+	if err != nil {
+		return nil, fmt.Errorf("regexMatch: %w", err)
+	}
+	return re.MatchString(toString(params[0])), nil
+}
+
+// regexFind returns the first match of the regular expression in the string.
+func regexFind(params ...any) (any, error) {
+	if err := arity("regexFind", params, 2, 2); err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(toString(params[1]))
+	if err != nil {
+		return nil, fmt.Errorf("regexFind: %w", err)
+	}
+	return re.FindString(toString(params[0])), nil
+}
+
+// regexReplaceAll replaces all matches of the regular expression.
+// The replacement supports $1 style submatch expansion.
+func regexReplaceAll(params ...any) (any, error) {
+	if err := arity("regexReplaceAll", params, 3, 3); err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(toString(params[1]))
+	if err != nil {
+		return nil, fmt.Errorf("regexReplaceAll: %w", err)
+	}
+	return re.ReplaceAllString(toString(params[0]), toString(params[2])), nil
+}
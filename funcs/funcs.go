@@ -0,0 +1,203 @@
+// Package funcs provides a standard library of template functions for yamlexpr expressions.
+//
+// The functions complement the expr-lang builtins with string case conversion,
+// indentation, quoting, defaults, encoding, hashing, regular expressions,
+// semantic version comparison and map/list manipulation.
+//
+// The value being transformed is always the first argument, so the functions
+// compose with the expr-lang pipe operator:
+//
+//	name: ${ title | kebabcase | trunc(63) }
+//
+// Register the library with:
+//
+//	e := yamlexpr.New(fs, funcs.With(nil))
+package funcs
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/expr-lang/expr"
+
+	"github.com/titpetric/yamlexpr/model"
+)
+
+// Function is a template function callable from expressions.
+type Function func(params ...any) (any, error)
+
+// Options configure the function library.
+type Options struct {
+	// Now returns the current time for now() (default: time.Now).
+	// Override it for deterministic output in tests.
+	Now func() time.Time
+}
+
+// Functions returns the function library keyed by function name.
+func Functions(options *Options) map[string]Function {
+	if options == nil {
+		options = &Options{}
+	}
+	now := options.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	return map[string]Function{
+		// strings
+		"title":     title,
+		"camelcase": camelcase,
+		"snakecase": snakecase,
+		"kebabcase": kebabcase,
+		"trunc":     trunc,
+		"indent":    indent,
+		"nindent":   nindent,
+		"quote":     quote,
+		"squote":    squote,
+
+		// defaults
+		"default":  defaultValue,
+		"coalesce": coalesce,
+		"empty":    empty,
+
+		// encoding
+		"toJson":   toJson,
+		"fromJson": fromJson,
+		"toYaml":   toYaml,
+		"fromYaml": fromYaml,
+		"b64enc":   b64enc,
+		"b64dec":   b64dec,
+
+		// hashing
+		"sha256sum": sha256sum,
+		"sha1sum":   sha1sum,
+		"md5sum":    md5sum,
+		"uuidv5":    uuidv5,
+
+		// regular expressions
+		"regexMatch":      regexMatch,
+		"regexFind":       regexFind,
+		"regexReplaceAll": regexReplaceAll,
+
+		// semantic versions
+		"semverCmp":     semverCmp,
+		"semverCompare": semverCompare,
+		"semverGt":      semverGt,
+		"semverLt":      semverLt,
+
+		// maps and lists
+		"dict":   dict,
+		"list":   list,
+		"merge":  merge,
+		"pick":   pick,
+		"omit":   omit,
+		"keys":   keys,
+		"values": values,
+		"hasKey": hasKey,
+
+		// time
+		"now": func(params ...any) (any, error) {
+			if err := arity("now", params, 0, 0); err != nil {
+				return nil, err
+			}
+			return now(), nil
+		},
+	}
+}
+
+// ExprOptions returns the expr-lang options registering the function library.
+// Functions named like expr-lang builtins (keys, values, now) take precedence.
+func ExprOptions(options *Options) []expr.Option {
+	fns := Functions(options)
+
+	names := make([]string, 0, len(fns))
+	for name := range fns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	opts := make([]expr.Option, 0, len(names))
+	for _, name := range names {
+		opts = append(opts, expr.Function(name, fns[name]))
+	}
+	return opts
+}
+
+// With returns a ConfigOption registering the function library.
+func With(options *Options) model.ConfigOption {
+	return model.WithExprOptions(ExprOptions(options)...)
+}
+
+// arity checks the number of parameters passed to a function.
+// A negative max allows any number of parameters.
+func arity(name string, params []any, min, max int) error {
+	if len(params) < min || (max >= 0 && len(params) > max) {
+		switch {
+		case min == max:
+			return fmt.Errorf("%s: expected %d arguments, got %d", name, min, len(params))
+		case max < 0:
+			return fmt.Errorf("%s: expected at least %d arguments, got %d", name, min, len(params))
+		default:
+			return fmt.Errorf("%s: expected %d to %d arguments, got %d", name, min, max, len(params))
+		}
+	}
+	return nil
+}
+
+// toString converts a value to its string representation.
+func toString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	case fmt.Stringer:
+		return val.String()
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// toInt converts a numeric value to int.
+func toInt(name string, v any) (int, error) {
+	switch val := v.(type) {
+	case int:
+		return val, nil
+	case int8:
+		return int(val), nil
+	case int16:
+		return int(val), nil
+	case int32:
+		return int(val), nil
+	case int64:
+		return int(val), nil
+	case uint:
+		return int(val), nil
+	case uint8:
+		return int(val), nil
+	case uint16:
+		return int(val), nil
+	case uint32:
+		return int(val), nil
+	case uint64:
+		return int(val), nil
+	case float32:
+		return int(val), nil
+	case float64:
+		return int(val), nil
+	default:
+		return 0, fmt.Errorf("%s: expected a number, got %T", name, v)
+	}
+}
+
+// toMap asserts a value is a map[string]any.
+func toMap(name string, v any) (map[string]any, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: expected a map, got %T", name, v)
+	}
+	return m, nil
+}
@@ -0,0 +1,159 @@
+package funcs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/yamlexpr"
+	"github.com/titpetric/yamlexpr/funcs"
+)
+
+// eval evaluates an expression with the function library registered.
+func eval(t *testing.T, input string, env map[string]any) (any, error) {
+	t.Helper()
+
+	now := func() time.Time {
+		return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+	opts := append([]expr.Option{expr.Env(env)}, funcs.ExprOptions(&funcs.Options{Now: now})...)
+	program, err := expr.Compile(input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return expr.Run(program, env)
+}
+
+// TestFunctions tests the function library.
+func TestFunctions(t *testing.T) {
+	env := map[string]any{
+		"name":   "Hello World",
+		"blank":  "",
+		"config": map[string]any{"a": 1, "b": map[string]any{"c": 2}, "d": 3},
+		"extra":  map[string]any{"b": map[string]any{"e": 4}},
+		"block":  "a: 1\nb: 2",
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected any
+	}{
+		{"title", `title("hello world")`, "Hello World"},
+		{"camelcase", `camelcase("hello_world-name")`, "helloWorldName"},
+		{"snakecase", `snakecase("HTTPServerName")`, "http_server_name"},
+		{"kebabcase", `kebabcase(name)`, "hello-world"},
+		{"trunc", `trunc("abcdef", 3)`, "abc"},
+		{"trunc negative", `trunc("abcdef", -2)`, "ef"},
+		{"trunc short", `trunc("ab", 3)`, "ab"},
+		{"indent", `indent(block, 2)`, "  a: 1\n  b: 2"},
+		{"nindent", `nindent(block, 2)`, "\n  a: 1\n  b: 2"},
+		{"quote", `quote(name)`, `"Hello World"`},
+		{"squote", `squote(name)`, `'Hello World'`},
+		{"default empty", `default(blank, "fallback")`, "fallback"},
+		{"default set", `default(name, "fallback")`, "Hello World"},
+		{"coalesce", `coalesce(nil, blank, 0, "first", "second")`, "first"},
+		{"empty", `empty(blank)`, true},
+		{"toJson", `toJson(config)`, `{"a":1,"b":{"c":2},"d":3}`},
+		{"fromJson", `fromJson("{\"a\": [1, 2]}").a[1]`, 2.0},
+		{"toYaml", `toYaml(pick(config, "a", "d"))`, "a: 1\nd: 3"},
+		{"fromYaml", `fromYaml(block).b`, 2},
+		{"b64enc", `b64enc("hello")`, "aGVsbG8="},
+		{"b64dec", `b64dec("aGVsbG8=")`, "hello"},
+		{"sha256sum", `sha256sum("hello")`, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{"sha1sum", `sha1sum("hello")`, "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"},
+		{"md5sum", `md5sum("hello")`, "5d41402abc4b2a76b9719d911017c592"},
+		{"uuidv5", `uuidv5("dns", "www.example.com")`, "2ed6657d-e927-568b-95e1-2665a8aea6a2"},
+		{"regexMatch", `regexMatch("v1.2.3", "^v[0-9]+")`, true},
+		{"regexFind", `regexFind("release-1.2.3", "[0-9.]+")`, "1.2.3"},
+		{"regexReplaceAll", `regexReplaceAll("a1b22c", "[0-9]+", "-")`, "a-b-c"},
+		{"semverCmp", `semverCmp("1.2.3", "v1.10.0")`, -1},
+		{"semverCompare", `semverCompare(">=1.2.0", "v1.10.0")`, true},
+		{"semverCompare caret", `semverCompare("^1.0.0", "2.0.0")`, false},
+		{"semverCompare tilde", `semverCompare("~1.2", "1.2.9")`, true},
+		{"semverCompare wildcard", `semverCompare("1.x", "1.9.0")`, true},
+		{"semverCompare range", `semverCompare(">= 1.2, < 1.3", "1.3.0")`, false},
+		{"semverCompare or", `semverCompare("<1.0.0 || ^2.1", "2.5.0")`, true},
+		{"semverCompare prerelease", `semverCompare(">=1.21.0-0", "v1.21.3-gke.1")`, true},
+		{"semverGt", `semverGt("1.0.0", "1.0.0-rc.1")`, true},
+		{"semverLt prerelease", `semverLt("1.0.0-alpha", "1.0.0-alpha.1")`, true},
+		{"dict", `dict("a", 1, "b", 2)`, map[string]any{"a": 1, "b": 2}},
+		{"list", `list(1, "a")`, []any{1, "a"}},
+		{"merge", `merge(config, extra)`, map[string]any{"a": 1, "b": map[string]any{"c": 2, "e": 4}, "d": 3}},
+		{"pick", `pick(config, "a", "missing")`, map[string]any{"a": 1}},
+		{"omit", `omit(config, "b", "d")`, map[string]any{"a": 1}},
+		{"keys", `keys(config)`, []any{"a", "b", "d"}},
+		{"values", `values(omit(config, "b"))`, []any{1, 3}},
+		{"hasKey", `hasKey(config, "d")`, true},
+		{"now", `now().Year()`, 2024},
+		{"pipe", `name | kebabcase() | trunc(5) | upper()`, "HELLO"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := eval(t, tt.input, env)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+// TestFunctions_MergeKeepsInput tests merge doesn't modify the merged maps.
+func TestFunctions_MergeKeepsInput(t *testing.T) {
+	env := map[string]any{
+		"config": map[string]any{"b": map[string]any{"c": 2}},
+		"extra":  map[string]any{"b": map[string]any{"e": 4}},
+	}
+
+	_, err := eval(t, `merge(config, extra)`, env)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"b": map[string]any{"c": 2}}, env["config"])
+}
+
+// TestFunctions_Errors tests error reporting of the function library.
+func TestFunctions_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"arity", `trunc("abc")`},
+		{"invalid number", `indent("abc", "x")`},
+		{"invalid json", `fromJson("{")`},
+		{"invalid base64", `b64dec("!!")`},
+		{"invalid regex", `regexMatch("abc", "[")`},
+		{"invalid version", `semverGt("x.y", "1.0")`},
+		{"invalid constraint", `semverCompare(">=1.x.2", "1.0")`},
+		{"empty constraint", `semverCompare("", "1.0")`},
+		{"invalid namespace", `uuidv5("example", "name")`},
+		{"dict pairs", `dict("a")`},
+		{"not a map", `keys("abc")`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := eval(t, tt.input, map[string]any{})
+			require.Error(t, err)
+		})
+	}
+}
+
+// TestWith tests registering the function library with yamlexpr.
+func TestWith(t *testing.T) {
+	e := yamlexpr.New(nil, funcs.With(nil))
+
+	docs, err := e.Parse(map[string]any{
+		"service": "My Service",
+		"name":    "${kebabcase(service)}",
+		"labels":  "${toJson(dict(\"app\", kebabcase(service)))}",
+		"enabled": map[string]any{
+			"if":    `semverGt("2.0.0", "1.9.9")`,
+			"value": true,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "my-service", docs[0].Map()["name"])
+	require.Equal(t, `{"app":"my-service"}`, docs[0].Map()["labels"])
+	require.Equal(t, map[string]any{"value": true}, docs[0].Map()["enabled"])
+}
@@ -0,0 +1,300 @@
+package funcs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// version is a parsed semantic version.
+type version struct {
+	major, minor, patch int
+	prerelease          []string
+}
+
+// parseVersion parses a semantic version like "v1.2.3-rc.1+build".
+// Missing minor and patch components default to zero.
+func parseVersion(s string) (*version, error) {
+	v := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+
+	result := &version{}
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		result.prerelease = strings.Split(v[i+1:], ".")
+		v = v[:i]
+	}
+
+	parts := strings.Split(v, ".")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("invalid version %q", s)
+	}
+	numbers := []*int{&result.major, &result.minor, &result.patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q", s)
+		}
+		*numbers[i] = n
+	}
+	return result, nil
+}
+
+// compare returns -1, 0 or 1 when v is lower, equal or greater than o.
+func (v *version) compare(o *version) int {
+	for _, pair := range [][2]int{{v.major, o.major}, {v.minor, o.minor}, {v.patch, o.patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// A version without prerelease has higher precedence
+	switch {
+	case len(v.prerelease) == 0 && len(o.prerelease) == 0:
+		return 0
+	case len(v.prerelease) == 0:
+		return 1
+	case len(o.prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.prerelease) && i < len(o.prerelease); i++ {
+		a, b := v.prerelease[i], o.prerelease[i]
+		if a == b {
+			continue
+		}
+		an, aerr := strconv.Atoi(a)
+		bn, berr := strconv.Atoi(b)
+		switch {
+		case aerr == nil && berr == nil:
+			if an < bn {
+				return -1
+			}
+			return 1
+		case aerr == nil:
+			// Numeric identifiers have lower precedence
+			return -1
+		case berr == nil:
+			return 1
+		case a < b:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	switch {
+	case len(v.prerelease) < len(o.prerelease):
+		return -1
+	case len(v.prerelease) > len(o.prerelease):
+		return 1
+	}
+	return 0
+}
+
+// compareVersions parses and compares two version parameters.
+func compareVersions(name string, params []any) (int, error) {
+	if err := arity(name, params, 2, 2); err != nil {
+		return 0, err
+	}
+	a, err := parseVersion(toString(params[0]))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	b, err := parseVersion(toString(params[1]))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return a.compare(b), nil
+}
+
+// semverCmp returns -1, 0 or 1 when the first version is lower, equal or greater than the second.
+func semverCmp(params ...any) (any, error) {
+	return compareVersions("semverCmp", params)
+}
+
+// semverCompare returns true if a version satisfies a constraint, like the Sprig function:
+// `semverCompare(">=1.2.0", version)`. See parseConstraint for the constraint syntax.
+func semverCompare(params ...any) (any, error) {
+	if err := arity("semverCompare", params, 2, 2); err != nil {
+		return nil, err
+	}
+	c, err := parseConstraint(toString(params[0]))
+	if err != nil {
+		return nil, fmt.Errorf("semverCompare: %w", err)
+	}
+	v, err := parseVersion(toString(params[1]))
+	if err != nil {
+		return nil, fmt.Errorf("semverCompare: %w", err)
+	}
+	return c.check(v), nil
+}
+
+// semverGt returns true if the first version is greater than the second.
+func semverGt(params ...any) (any, error) {
+	c, err := compareVersions("semverGt", params)
+	return c > 0, err
+}
+
+// semverLt returns true if the first version is lower than the second.
+func semverLt(params ...any) (any, error) {
+	c, err := compareVersions("semverLt", params)
+	return c < 0, err
+}
+
+// constraint is a parsed version constraint: a version satisfies the
+// constraint if it matches all the ranges of any group.
+type constraint [][]versionRange
+
+// versionRange reports whether a version is in a range.
+type versionRange func(v *version) bool
+
+// parseConstraint parses a version constraint like ">=1.2.0 <2.0.0 || ^3.1".
+//
+// Ranges separated by spaces or commas must all match, groups separated by ||
+// are alternatives. The operators are =, !=, >, <, >=, <=, ~ (patch updates,
+// ~1.2.3 is >=1.2.3 <1.3.0) and ^ (compatible updates, ^1.2.3 is >=1.2.3
+// <2.0.0). Partial versions like 1.2 or 1.2.x match every version they
+// prefix. Prerelease versions are compared by precedence.
+func parseConstraint(s string) (constraint, error) {
+	var c constraint
+	for _, group := range strings.Split(s, "||") {
+		var ranges []versionRange
+		terms := strings.FieldsFunc(group, func(r rune) bool { return r == ' ' || r == ',' })
+		for i := 0; i < len(terms); i++ {
+			// Operators may be separated from the version, e.g. ">= 1.2"
+			term := terms[i]
+			if strings.Trim(term, "=<>!~^") == "" && i+1 < len(terms) {
+				i++
+				term += terms[i]
+			}
+			r, err := parseRange(term)
+			if err != nil {
+				return nil, fmt.Errorf("invalid constraint %q: %w", s, err)
+			}
+			ranges = append(ranges, r)
+		}
+		if len(ranges) == 0 {
+			return nil, fmt.Errorf("invalid constraint %q", s)
+		}
+		c = append(c, ranges)
+	}
+	return c, nil
+}
+
+// parseRange parses a constraint term like ">=1.2.0", "~1.2" or "1.x".
+func parseRange(term string) (versionRange, error) {
+	op := "="
+	for _, prefix := range []string{">=", "<=", "!=", "=", ">", "<", "~", "^"} {
+		if strings.HasPrefix(term, prefix) {
+			op, term = prefix, term[len(prefix):]
+			break
+		}
+	}
+
+	// Count the version components before wildcards or omitted components
+	core := strings.TrimPrefix(term, "v")
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+	parts := strings.Split(core, ".")
+	n := 0
+	for n < len(parts) && !isWildcard(parts[n]) {
+		n++
+	}
+	for _, part := range parts[n:] {
+		if !isWildcard(part) {
+			return nil, fmt.Errorf("invalid version %q", term)
+		}
+	}
+	if n == 0 {
+		return func(*version) bool { return true }, nil
+	}
+	if n < len(parts) {
+		term = strings.Join(parts[:n], ".")
+	}
+	lower, err := parseVersion(term)
+	if err != nil {
+		return nil, err
+	}
+
+	// upper is the lowest version not prefixed by a partial version
+	upper := lower
+	switch n {
+	case 1:
+		upper = &version{major: lower.major + 1}
+	case 2:
+		upper = &version{major: lower.major, minor: lower.minor + 1}
+	}
+	partial := n < 3
+	between := func(v, lower, upper *version) bool {
+		return v.compare(lower) >= 0 && v.compare(upper) < 0
+	}
+
+	switch op {
+	case "~":
+		if n > 1 {
+			upper = &version{major: lower.major, minor: lower.minor + 1}
+		}
+		return func(v *version) bool { return between(v, lower, upper) }, nil
+	case "^":
+		switch {
+		case lower.major > 0 || n == 1:
+			upper = &version{major: lower.major + 1}
+		case lower.minor > 0 || n == 2:
+			upper = &version{minor: lower.minor + 1}
+		default:
+			upper = &version{patch: lower.patch + 1}
+		}
+		return func(v *version) bool { return between(v, lower, upper) }, nil
+	case "=":
+		if partial {
+			return func(v *version) bool { return between(v, lower, upper) }, nil
+		}
+		return func(v *version) bool { return v.compare(lower) == 0 }, nil
+	case "!=":
+		if partial {
+			return func(v *version) bool { return !between(v, lower, upper) }, nil
+		}
+		return func(v *version) bool { return v.compare(lower) != 0 }, nil
+	case ">":
+		if partial {
+			return func(v *version) bool { return v.compare(upper) >= 0 }, nil
+		}
+		return func(v *version) bool { return v.compare(lower) > 0 }, nil
+	case "<=":
+		if partial {
+			return func(v *version) bool { return v.compare(upper) < 0 }, nil
+		}
+		return func(v *version) bool { return v.compare(lower) <= 0 }, nil
+	case ">=":
+		return func(v *version) bool { return v.compare(lower) >= 0 }, nil
+	}
+	return func(v *version) bool { return v.compare(lower) < 0 }, nil
+}
+
+// isWildcard reports whether a version component is a wildcard.
+func isWildcard(part string) bool {
+	return part == "x" || part == "X" || part == "*"
+}
+
+// check reports whether v satisfies the constraint.
+func (c constraint) check(v *version) bool {
+	for _, group := range c {
+		matched := true
+		for _, r := range group {
+			if !r(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
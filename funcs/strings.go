@@ -0,0 +1,149 @@
+package funcs
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// words splits a string into words on case changes, digits boundaries and separators.
+// For example, "HTTPServer_name-v2" becomes ["HTTP", "Server", "name", "v2"].
+func words(s string) []string {
+	var result []string
+	var current []rune
+
+	runes := []rune(s)
+	flush := func() {
+		if len(current) > 0 {
+			result = append(result, string(current))
+			current = nil
+		}
+	}
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(current) > 0 {
+			prev := current[len(current)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+
+	return result
+}
+
+// title upper-cases the first letter of each word, e.g. "hello world" becomes "Hello World".
+func title(params ...any) (any, error) {
+	if err := arity("title", params, 1, 1); err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(toString(params[0]))
+	for i, field := range fields {
+		r := []rune(field)
+		r[0] = unicode.ToUpper(r[0])
+		fields[i] = string(r)
+	}
+	return strings.Join(fields, " "), nil
+}
+
+// camelcase converts a string to camelCase, e.g. "hello_world" becomes "helloWorld".
+func camelcase(params ...any) (any, error) {
+	if err := arity("camelcase", params, 1, 1); err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	for i, word := range words(toString(params[0])) {
+		word = strings.ToLower(word)
+		if i > 0 {
+			r := []rune(word)
+			r[0] = unicode.ToUpper(r[0])
+			word = string(r)
+		}
+		b.WriteString(word)
+	}
+	return b.String(), nil
+}
+
+// snakecase converts a string to snake_case, e.g. "helloWorld" becomes "hello_world".
+func snakecase(params ...any) (any, error) {
+	if err := arity("snakecase", params, 1, 1); err != nil {
+		return nil, err
+	}
+	return strings.ToLower(strings.Join(words(toString(params[0])), "_")), nil
+}
+
+// kebabcase converts a string to kebab-case, e.g. "helloWorld" becomes "hello-world".
+func kebabcase(params ...any) (any, error) {
+	if err := arity("kebabcase", params, 1, 1); err != nil {
+		return nil, err
+	}
+	return strings.ToLower(strings.Join(words(toString(params[0])), "-")), nil
+}
+
+// trunc truncates a string to at most n characters.
+// A negative n keeps the last n characters.
+func trunc(params ...any) (any, error) {
+	if err := arity("trunc", params, 2, 2); err != nil {
+		return nil, err
+	}
+	n, err := toInt("trunc", params[1])
+	if err != nil {
+		return nil, err
+	}
+	r := []rune(toString(params[0]))
+	switch {
+	case n >= 0 && len(r) > n:
+		return string(r[:n]), nil
+	case n < 0 && len(r) > -n:
+		return string(r[len(r)+n:]), nil
+	}
+	return string(r), nil
+}
+
+// indent prefixes every line of a string with n spaces.
+func indent(params ...any) (any, error) {
+	if err := arity("indent", params, 2, 2); err != nil {
+		return nil, err
+	}
+	n, err := toInt("indent", params[1])
+	if err != nil {
+		return nil, err
+	}
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(toString(params[0]), "\n", "\n"+pad), nil
+}
+
+// nindent is like indent, but prepends a newline.
+func nindent(params ...any) (any, error) {
+	if err := arity("nindent", params, 2, 2); err != nil {
+		return nil, err
+	}
+	s, err := indent(params...)
+	if err != nil {
+		return nil, err
+	}
+	return "\n" + s.(string), nil
+}
+
+// quote wraps a value in double quotes, escaping as needed.
+func quote(params ...any) (any, error) {
+	if err := arity("quote", params, 1, 1); err != nil {
+		return nil, err
+	}
+	return strconv.Quote(toString(params[0])), nil
+}
+
+// squote wraps a value in single quotes.
+func squote(params ...any) (any, error) {
+	if err := arity("squote", params, 1, 1); err != nil {
+		return nil, err
+	}
+	return "'" + toString(params[0]) + "'", nil
+}
@@ -0,0 +1,99 @@
+package yamlexpr_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+// TestExpr_WithFunction tests custom functions at every compile site.
+func TestExpr_WithFunction(t *testing.T) {
+	slug := func(params ...any) (any, error) {
+		return strings.ToLower(strings.ReplaceAll(params[0].(string), " ", "-")), nil
+	}
+	platforms := func(params ...any) (any, error) {
+		return []any{"linux", "darwin"}, nil
+	}
+
+	e := yamlexpr.New(nil,
+		yamlexpr.WithFunction("slug", slug, new(func(string) string)),
+		yamlexpr.WithFunction("platforms", platforms),
+	)
+
+	t.Run("interpolation", func(t *testing.T) {
+		docs, err := e.Parse(map[string]any{
+			"title": "Hello World",
+			"name":  "${slug(title)}",
+			"path":  "/posts/${slug(title)}.html",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "hello-world", docs[0].Map()["name"])
+		require.Equal(t, "/posts/hello-world.html", docs[0].Map()["path"])
+	})
+
+	t.Run("condition", func(t *testing.T) {
+		docs, err := e.Parse(map[string]any{
+			"title": "Hello World",
+			"match": map[string]any{
+				"if":    `slug(title) == "hello-world"`,
+				"value": true,
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"value": true}, docs[0].Map()["match"])
+	})
+
+	t.Run("for source", func(t *testing.T) {
+		docs, err := e.Parse(map[string]any{
+			"builds": []any{
+				map[string]any{
+					"for":  "os in platforms()",
+					"name": "build-${os}",
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []any{
+			map[string]any{"name": "build-linux"},
+			map[string]any{"name": "build-darwin"},
+		}, docs[0].Map()["builds"])
+	})
+
+	t.Run("matrix", func(t *testing.T) {
+		docs, err := e.Parse(map[string]any{
+			"jobs": []any{
+				map[string]any{
+					"matrix": map[string]any{
+						"os":      "${platforms()}",
+						"version": "${1..2}",
+					},
+					"name": "${os}-${version}",
+				},
+			},
+		})
+		require.NoError(t, err)
+		jobs := docs[0].Map()["jobs"].([]any)
+		require.Len(t, jobs, 4)
+		require.Equal(t, "linux-1", jobs[0].(map[string]any)["name"])
+	})
+}
+
+// TestExpr_WithExprOptions tests passing expr-lang options.
+func TestExpr_WithExprOptions(t *testing.T) {
+	e := yamlexpr.New(nil, yamlexpr.WithExprOptions(
+		expr.Function("double", func(params ...any) (any, error) {
+			return params[0].(int) * 2, nil
+		}),
+	))
+
+	docs, err := e.Parse(map[string]any{
+		"count":  21,
+		"answer": "${double(count)}",
+	})
+	require.NoError(t, err)
+	require.Equal(t, 42, docs[0].Map()["answer"])
+}
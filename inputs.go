@@ -0,0 +1,313 @@
+package yamlexpr
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/titpetric/yamlexpr/stack"
+)
+
+// Input types supported by input declarations.
+const (
+	InputString  = "string"
+	InputNumber  = "number"
+	InputInteger = "integer"
+	InputBoolean = "boolean"
+	InputList    = "list"
+	InputMap     = "map"
+	InputAny     = "any"
+)
+
+// Input is a variable declared by the inputs directive of a template.
+//
+// Inputs are declared in the root of a document:
+//
+//	inputs:
+//	  env:
+//	    description: Deployment environment
+//	    type: string
+//	    enum: [dev, prod]
+//	    default: dev
+//	  replicas:
+//	    type: integer
+//	    required: true
+//
+// Supplied variables are validated against the declarations before processing,
+// and defaults are applied for inputs that aren't supplied.
+type Input struct {
+	// Name is the variable name.
+	Name string `json:"name" yaml:"name"`
+	// Type is one of string, number, integer, boolean, list, map or any (default).
+	Type string `json:"type" yaml:"type"`
+	// Description documents the input.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Default is the value used when the input isn't supplied.
+	Default any `json:"default,omitempty" yaml:"default,omitempty"`
+	// Required inputs without a default must be supplied.
+	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
+	// Enum lists the allowed values, if set.
+	Enum []any `json:"enum,omitempty" yaml:"enum,omitempty"`
+}
+
+// Inputs returns the inputs declared by a template file.
+// The filename is resolved relative to the filesystem provided to New().
+// Inputs are returned in declaration order.
+func (e *Expr) Inputs(filename string) ([]Input, error) {
+	node, doc, err := e.readDocument(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs, err := e.parseInputs(doc[e.config.InputsDirective()], node)
+	if err != nil {
+		return nil, fmt.Errorf("error in file %s: %w", filename, err)
+	}
+	return inputs, nil
+}
+
+// Inputs returns the inputs declared by the template.
+func (t *Template) Inputs() []Input {
+	return append([]Input(nil), t.inputs...)
+}
+
+// parseInputs parses the value of the inputs directive. Inputs are ordered
+// as declared in the document node, or by name without a node.
+func (e *Expr) parseInputs(value any, node *yaml.Node) ([]Input, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	directive := e.config.InputsDirective()
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s must be a map, got %T", directive, value)
+	}
+
+	inputs := make([]Input, 0, len(m))
+	for _, name := range declarationOrder(m, node, directive) {
+		input, err := parseInput(name, m[name], directive+"."+name)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, nil
+}
+
+// declarationOrder returns the keys of m in the order of the key mapping in
+// a document node. Keys not found in the node are sorted and placed last.
+func declarationOrder(m map[string]any, node *yaml.Node, key string) []string {
+	keys := make([]string, 0, len(m))
+	seen := make(map[string]bool, len(m))
+	if node != nil {
+		if _, mapping := mappingEntry(contentNode(node), key); mapping != nil {
+			mapping = resolveAlias(mapping)
+			for i := 0; i+1 < len(mapping.Content) && mapping.Kind == yaml.MappingNode; i += 2 {
+				k := mapping.Content[i].Value
+				if _, ok := m[k]; ok && !seen[k] {
+					keys = append(keys, k)
+					seen[k] = true
+				}
+			}
+		}
+	}
+
+	var rest []string
+	for k := range m {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// parseInput parses a single input declaration.
+// An empty declaration declares an optional input of any type.
+func parseInput(name string, value any, path string) (Input, error) {
+	input := Input{
+		Name: name,
+		Type: InputAny,
+	}
+	if value == nil {
+		return input, nil
+	}
+
+	m, ok := value.(map[string]any)
+	if !ok {
+		return input, fmt.Errorf("input declaration must be a map, got %T at %s", value, path)
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := m[k]
+		switch k {
+		case "type":
+			typ, ok := v.(string)
+			if !ok || !isInputType(typ) {
+				return input, fmt.Errorf("unknown input type '%v' at %s.type", v, path)
+			}
+			input.Type = typ
+		case "description":
+			input.Description = fmt.Sprint(v)
+		case "default":
+			input.Default = v
+		case "required":
+			required, ok := v.(bool)
+			if !ok {
+				return input, fmt.Errorf("required must be a boolean, got %T at %s.required", v, path)
+			}
+			input.Required = required
+		case "enum":
+			enum, ok := v.([]any)
+			if !ok {
+				return input, fmt.Errorf("enum must be a list, got %T at %s.enum", v, path)
+			}
+			input.Enum = enum
+		default:
+			return input, fmt.Errorf("unknown input field '%s' at %s", k, path)
+		}
+	}
+
+	if input.Default != nil {
+		if err := input.check(input.Default); err != nil {
+			return input, fmt.Errorf("invalid default: %w at %s.default", err, path)
+		}
+	}
+	return input, nil
+}
+
+// resolveInputs validates vars against the declared inputs.
+// It returns a copy of vars with defaults applied for inputs that weren't supplied.
+// All invalid inputs are reported. In strict mode, undeclared variables are reported too.
+func (e *Expr) resolveInputs(inputs []Input, vars map[string]any) (map[string]any, error) {
+	result := make(map[string]any, len(vars)+len(inputs))
+	for k, v := range vars {
+		result[k] = v
+	}
+
+	directive := e.config.InputsDirective()
+	declared := make(map[string]bool, len(inputs))
+
+	var errs []error
+	for _, input := range inputs {
+		declared[input.Name] = true
+		path := directive + "." + input.Name
+
+		val, ok := vars[input.Name]
+		if !ok {
+			switch {
+			case input.Default != nil:
+				result[input.Name] = input.Default
+			case input.Required:
+				errs = append(errs, fmt.Errorf("missing required input '%s' at %s", input.Name, path))
+			}
+			continue
+		}
+		if err := input.check(val); err != nil {
+			errs = append(errs, fmt.Errorf("invalid input '%s': %w at %s", input.Name, err, path))
+		}
+	}
+
+	if e.config.Strict && len(inputs) > 0 {
+		names := make([]string, 0, len(vars))
+		for k := range vars {
+			if !declared[k] {
+				names = append(names, k)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			errs = append(errs, fmt.Errorf("undeclared input '%s'", name))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// check validates a value against the input type and enum.
+func (i Input) check(val any) error {
+	if !isInputValue(i.Type, val) {
+		return fmt.Errorf("expected %s, got %T", i.Type, val)
+	}
+	if len(i.Enum) == 0 {
+		return nil
+	}
+	for _, allowed := range i.Enum {
+		if inputEqual(allowed, val) {
+			return nil
+		}
+	}
+	return fmt.Errorf("expected one of %v, got %v", i.Enum, val)
+}
+
+// isInputType reports whether typ is a supported input type.
+func isInputType(typ string) bool {
+	switch typ {
+	case InputString, InputNumber, InputInteger, InputBoolean, InputList, InputMap, InputAny:
+		return true
+	}
+	return false
+}
+
+// isInputValue reports whether val is of the input type.
+func isInputValue(typ string, val any) bool {
+	switch typ {
+	case InputString:
+		_, ok := val.(string)
+		return ok
+	case InputNumber:
+		_, ok := inputNumber(val)
+		return ok
+	case InputInteger:
+		f, ok := inputNumber(val)
+		return ok && f == float64(int64(f))
+	case InputBoolean:
+		_, ok := val.(bool)
+		return ok
+	case InputList:
+		return stack.IsSlice(val)
+	case InputMap:
+		switch val.(type) {
+		case map[string]any, Document:
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// inputNumber converts numeric values to float64.
+func inputNumber(val any) (float64, bool) {
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// inputEqual compares values for enum checks. Numbers are compared by value.
+func inputEqual(a, b any) bool {
+	if x, ok := inputNumber(a); ok {
+		y, ok := inputNumber(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
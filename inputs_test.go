@@ -0,0 +1,196 @@
+package yamlexpr_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+const inputsTemplate = `inputs:
+  env:
+    description: Deployment environment
+    type: string
+    enum: [dev, prod]
+    default: dev
+  replicas:
+    type: integer
+    required: true
+  labels:
+    type: map
+name: app-${env}
+replicas: ${replicas}
+`
+
+func TestExpr_Inputs(t *testing.T) {
+	fs := fstest.MapFS{
+		"app.yaml": {Data: []byte(inputsTemplate)},
+	}
+	e := yamlexpr.New(fs)
+
+	inputs, err := e.Inputs("app.yaml")
+	require.NoError(t, err)
+	require.Equal(t, []yamlexpr.Input{
+		{Name: "env", Type: "string", Description: "Deployment environment", Default: "dev", Enum: []any{"dev", "prod"}},
+		{Name: "replicas", Type: "integer", Required: true},
+		{Name: "labels", Type: "map"},
+	}, inputs)
+
+	t.Run("missing required input", func(t *testing.T) {
+		_, err := e.Load("app.yaml")
+		require.ErrorContains(t, err, "missing required input 'replicas' at inputs.replicas")
+	})
+}
+
+func TestExpr_Inputs_DeclarationOrder(t *testing.T) {
+	fs := fstest.MapFS{
+		"a.yaml": {Data: []byte("inputs:\n  b: {}\n  a: {}\nname: a\n")},
+		"b.yaml": {Data: []byte("inputs:\n  a: {}\n  b: {}\nname: b\n")},
+	}
+	e := yamlexpr.New(fs)
+
+	for _, tc := range []struct {
+		file string
+		want []string
+	}{
+		{file: "a.yaml", want: []string{"b", "a"}},
+		{file: "b.yaml", want: []string{"a", "b"}},
+		{file: "a.yaml", want: []string{"b", "a"}},
+	} {
+		inputs, err := e.Inputs(tc.file)
+		require.NoError(t, err)
+
+		names := make([]string, len(inputs))
+		for i, input := range inputs {
+			names[i] = input.Name
+		}
+		require.Equal(t, tc.want, names, tc.file)
+
+		tpl, err := e.LoadTemplate(tc.file)
+		require.NoError(t, err)
+		require.Equal(t, inputs, tpl.Inputs(), tc.file)
+	}
+}
+
+func TestTemplate_Inputs(t *testing.T) {
+	e := yamlexpr.New(nil)
+
+	tpl, err := e.Compile(map[string]any{
+		"inputs": map[string]any{
+			"env":      map[string]any{"type": "string", "enum": []any{"dev", "prod"}, "default": "dev"},
+			"replicas": map[string]any{"type": "integer", "required": true},
+			"extra":    nil,
+		},
+		"name":     "app-${env}",
+		"replicas": "${replicas}",
+	})
+	require.NoError(t, err)
+	require.Len(t, tpl.Inputs(), 3)
+
+	t.Run("defaults", func(t *testing.T) {
+		docs, err := tpl.Execute(map[string]any{"replicas": 3})
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"name": "app-dev", "replicas": 3}, docs[0].Map())
+	})
+
+	t.Run("supplied", func(t *testing.T) {
+		docs, err := tpl.Execute(map[string]any{"env": "prod", "replicas": 2.0})
+		require.NoError(t, err)
+		require.Equal(t, "app-prod", docs[0].Map()["name"])
+	})
+
+	tests := []struct {
+		name string
+		vars map[string]any
+		err  string
+	}{
+		{
+			name: "missing required",
+			vars: map[string]any{"env": "prod"},
+			err:  "missing required input 'replicas' at inputs.replicas",
+		},
+		{
+			name: "wrong type",
+			vars: map[string]any{"replicas": "3"},
+			err:  "invalid input 'replicas': expected integer, got string at inputs.replicas",
+		},
+		{
+			name: "not integral",
+			vars: map[string]any{"replicas": 1.5},
+			err:  "invalid input 'replicas': expected integer, got float64 at inputs.replicas",
+		},
+		{
+			name: "not in enum",
+			vars: map[string]any{"env": "qa", "replicas": 1},
+			err:  "invalid input 'env': expected one of [dev prod], got qa at inputs.env",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tpl.Execute(tc.vars)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+
+	t.Run("all errors are reported", func(t *testing.T) {
+		_, err := tpl.Execute(map[string]any{"env": 1})
+		require.ErrorContains(t, err, "invalid input 'env'")
+		require.ErrorContains(t, err, "missing required input 'replicas'")
+	})
+}
+
+func TestTemplate_Inputs_Strict(t *testing.T) {
+	e := yamlexpr.New(nil, yamlexpr.WithStrict())
+
+	tpl, err := e.Compile(map[string]any{
+		"inputs": map[string]any{"env": nil},
+		"name":   "app-${env}",
+	})
+	require.NoError(t, err)
+
+	_, err = tpl.Execute(map[string]any{"env": "dev", "evn": "prod"})
+	require.ErrorContains(t, err, "undeclared input 'evn'")
+}
+
+func TestExpr_Inputs_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs any
+		err    string
+	}{
+		{
+			name:   "not a map",
+			inputs: []any{"env"},
+			err:    "inputs must be a map, got []interface {}",
+		},
+		{
+			name:   "unknown type",
+			inputs: map[string]any{"env": map[string]any{"type": "str"}},
+			err:    "unknown input type 'str' at inputs.env.type",
+		},
+		{
+			name:   "unknown field",
+			inputs: map[string]any{"env": map[string]any{"defualt": "dev"}},
+			err:    "unknown input field 'defualt' at inputs.env",
+		},
+		{
+			name:   "invalid default",
+			inputs: map[string]any{"env": map[string]any{"enum": []any{"dev"}, "default": "prod"}},
+			err:    "invalid default: expected one of [dev], got prod at inputs.env.default",
+		},
+	}
+
+	e := yamlexpr.New(nil)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := e.Compile(map[string]any{"inputs": tc.inputs})
+			require.ErrorContains(t, err, tc.err)
+
+			_, err = e.Parse(map[string]any{"inputs": tc.inputs})
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
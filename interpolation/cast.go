@@ -0,0 +1,159 @@
+package interpolation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"gopkg.in/yaml.v3"
+)
+
+// castOptions register the cast functions used as filters, e.g. `${ port | int }`.
+// They replace the int and float builtins, extending them to strings with
+// surrounding whitespace and giving clear errors for invalid values.
+var castOptions = []expr.Option{
+	expr.Function("int", castInt),
+	expr.Function("float", castFloat),
+	expr.Function("bool", castBool),
+	expr.Function("json", castJSON),
+}
+
+// castArg returns the single argument of a cast function.
+func castArg(name string, params []any) (any, error) {
+	if len(params) != 1 {
+		return nil, fmt.Errorf("%s: expected 1 argument, got %d", name, len(params))
+	}
+	return params[0], nil
+}
+
+// castError returns an error for a value that can't be converted.
+func castError(val any, to string) error {
+	if s, ok := val.(string); ok {
+		return fmt.Errorf("cannot convert %q to %s", s, to)
+	}
+	return fmt.Errorf("cannot convert %T %v to %s", val, val, to)
+}
+
+// castInt converts numbers and numeric strings to int. Floats are truncated.
+func castInt(params ...any) (any, error) {
+	val, err := castArg("int", params)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := val.(type) {
+	case int:
+		return v, nil
+	case int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		if n, err := strconv.Atoi(fmt.Sprint(v)); err == nil {
+			return n, nil
+		}
+	case float32:
+		if n, ok := floatInt(float64(v)); ok {
+			return n, nil
+		}
+	case float64:
+		if n, ok := floatInt(v); ok {
+			return n, nil
+		}
+	case string:
+		s := strings.TrimSpace(v)
+		if n, err := strconv.Atoi(s); err == nil {
+			return n, nil
+		}
+		// Accept integral floats like "8080.0"
+		if f, err := strconv.ParseFloat(s, 64); err == nil && f == math.Trunc(f) {
+			if n, ok := floatInt(f); ok {
+				return n, nil
+			}
+		}
+	}
+	return nil, castError(val, "int")
+}
+
+// floatInt truncates f to int. It returns false for NaN, infinities and
+// values out of the int range.
+func floatInt(f float64) (int, bool) {
+	if math.IsNaN(f) || f < math.MinInt || f >= -math.MinInt {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// castFloat converts numbers and numeric strings to float64.
+func castFloat(params ...any) (any, error) {
+	val, err := castArg("float", params)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		f, _ := strconv.ParseFloat(fmt.Sprint(v), 64)
+		return f, nil
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f, nil
+		}
+	}
+	return nil, castError(val, "float")
+}
+
+// castBool converts booleans, 0 and 1, and boolean strings to bool.
+// Strings are matched case insensitively: true/false, yes/no, on/off, 1/0.
+func castBool(params ...any) (any, error) {
+	val, err := castArg("bool", params)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := val.(type) {
+	case bool:
+		return v, nil
+	case int:
+		switch v {
+		case 0:
+			return false, nil
+		case 1:
+			return true, nil
+		}
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "yes", "on", "1":
+			return true, nil
+		case "false", "no", "off", "0":
+			return false, nil
+		}
+	}
+	return nil, castError(val, "bool")
+}
+
+// castJSON decodes a JSON string into a value. Other values are returned as is.
+func castJSON(params ...any) (any, error) {
+	val, err := castArg("json", params)
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := val.(string)
+	if !ok {
+		return val, nil
+	}
+	if !json.Valid([]byte(s)) {
+		return nil, castError(val, "json")
+	}
+
+	// JSON is decoded as YAML, keeping integers as int
+	var result any
+	if err := yaml.Unmarshal([]byte(s), &result); err != nil {
+		return nil, castError(val, "json")
+	}
+	return result, nil
+}
@@ -0,0 +1,81 @@
+package interpolation_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/yamlexpr/interpolation"
+	"github.com/titpetric/yamlexpr/stack"
+)
+
+// TestCast tests converting values with cast filters.
+func TestCast(t *testing.T) {
+	st := stack.NewStack(map[string]any{
+		"port":    "8080",
+		"ratio":   "0.75",
+		"price":   2.7,
+		"flag":    "Yes",
+		"off":     "off",
+		"one":     1,
+		"cfg":     `{"name": "app", "replicas": 3, "tags": ["a", "b"]}`,
+		"cfgMap":  map[string]any{"name": "app"},
+		"invalid": "eighty",
+		"max":     uint64(math.MaxUint64),
+		"small":   int64(-5),
+		"huge":    1e300,
+		"nan":     math.NaN(),
+	})
+
+	tests := []struct {
+		input string
+		want  any
+	}{
+		{"${port | int}", 8080},
+		{"${ int(port) + 1 }", 8081},
+		{"${\"8080.0\" | int}", 8080},
+		{"${price | int}", 2},
+		{"${small | int}", -5},
+		{"${ratio | float}", 0.75},
+		{"${one | float}", 1.0},
+		{"${flag | bool}", true},
+		{"${off | bool}", false},
+		{"${one | bool}", true},
+		{"${cfg | json}", map[string]any{"name": "app", "replicas": 3, "tags": []any{"a", "b"}}},
+		{"${cfgMap | json}", map[string]any{"name": "app"}},
+		{"${(cfg | json).replicas}", 3},
+		{"port ${port | int}", "port 8080"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			val, err := interpolation.InterpolateValueWithContext(tc.input, st, "spec")
+			require.NoError(t, err)
+			require.Equal(t, tc.want, val)
+		})
+	}
+
+	failures := []struct {
+		input string
+		err   string
+	}{
+		{"${invalid | int}", `error evaluating expression 'invalid | int' at spec.port: cannot convert "eighty" to int`},
+		{"${ratio | int}", `cannot convert "0.75" to int`},
+		{"${max | int}", `cannot convert uint64 18446744073709551615 to int`},
+		{"${huge | int}", `cannot convert float64 1e+300 to int`},
+		{"${nan | int}", `cannot convert float64 NaN to int`},
+		{"${\"1e30\" | int}", `cannot convert "1e30" to int`},
+		{"${invalid | float}", `cannot convert "eighty" to float`},
+		{"${invalid | bool}", `cannot convert "eighty" to bool`},
+		{"${price | bool}", `cannot convert float64 2.7 to bool`},
+		{"${invalid | json}", `cannot convert "eighty" to json`},
+	}
+
+	for _, tc := range failures {
+		t.Run(tc.input, func(t *testing.T) {
+			_, err := interpolation.InterpolateValueWithContext(tc.input, st, "spec.port")
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,425 @@
+package interpolation
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/parser"
+	"github.com/expr-lang/expr/types"
+	"github.com/expr-lang/expr/vm"
+
+	"github.com/titpetric/yamlexpr/stack"
+)
+
+// programCacheLimit limits the number of cached compiled programs per Evaluator.
+const programCacheLimit = 4096
+
+// Evaluator compiles and evaluates expressions against a stack.
+//
+// Expressions are compiled against a dynamic environment: only the names of
+// variables referenced by the expression and defined in scope are declared,
+// with their values typed as any. Compiled programs are cached by expression
+// and are reused across loop iterations, matrix jobs and documents.
+// Values are looked up from the stack for each evaluation, avoiding a copy
+// of the whole stack.
+//
+// An Evaluator is safe for concurrent use.
+type Evaluator struct {
+	opts      []expr.Option
+	undefined UndefinedFunc
+
+	mu          sync.RWMutex
+	expressions map[string]*expression
+	programs    map[string]*compiled
+}
+
+// expression holds the analysis of an expression source.
+type expression struct {
+	// names are the sorted identifiers referenced by the expression.
+	names []string
+	// defaults is true if the expression uses the ?? operator.
+	defaults bool
+
+	once      sync.Once
+	variables map[string]bool
+	err       error
+}
+
+// compiled holds a cached compilation result.
+type compiled struct {
+	program *vm.Program
+	err     error
+}
+
+// Program is a compiled expression bound to the variables of a scope.
+type Program struct {
+	program *vm.Program
+	env     map[string]any
+}
+
+// Run evaluates the program.
+func (p *Program) Run() (any, error) {
+	return expr.Run(p.program, p.env)
+}
+
+// defaultEvaluator is used by package level functions when no options are given.
+var defaultEvaluator = NewEvaluator()
+
+// NewEvaluator returns an Evaluator applying opts when compiling expressions.
+func NewEvaluator(opts ...expr.Option) *Evaluator {
+	return &Evaluator{
+		opts:        opts,
+		expressions: make(map[string]*expression),
+		programs:    make(map[string]*compiled),
+	}
+}
+
+// evaluatorFor returns the shared default evaluator, or a new evaluator with opts.
+func evaluatorFor(opts []expr.Option) *Evaluator {
+	if len(opts) == 0 {
+		return defaultEvaluator
+	}
+	return NewEvaluator(opts...)
+}
+
+// Options returns the expression options of the evaluator.
+func (e *Evaluator) Options() []expr.Option {
+	return e.opts
+}
+
+// SetUndefined sets the policy for undefined variables, see UndefinedFunc.
+// With a nil policy (the default), undefined variables are errors.
+// It must be called before the evaluator is used.
+func (e *Evaluator) SetUndefined(fn UndefinedFunc) {
+	e.undefined = fn
+}
+
+// Undefined returns the policy for undefined variables, or nil if none is set.
+func (e *Evaluator) Undefined() UndefinedFunc {
+	return e.undefined
+}
+
+// UndefinedValue applies the undefined policy to a variable used as a value,
+// in an expression, a condition or a for source. Placeholder text returned by
+// the policy, like the variable name returned by UndefinedKeep, is null.
+// Without a policy, undefined variables are errors.
+func (e *Evaluator) UndefinedValue(u Undefined) (any, error) {
+	if e.undefined == nil {
+		return UndefinedError(u)
+	}
+	val, err := e.undefined(u)
+	if s, ok := val.(string); ok && s == u.Raw {
+		return nil, err
+	}
+	return val, err
+}
+
+// Compile compiles an expression in the scope of the stack.
+// Filters may be piped without parentheses, e.g. `name | lower | trunc(63)`.
+//
+// Referencing a variable not defined in the stack is a compile error,
+// unless the expression uses the ?? operator, in which case undefined
+// variables are null, e.g. `port ?? 8080`. If an undefined policy is set,
+// undefined variables take the value returned by UndefinedValue.
+func (e *Evaluator) Compile(input string, st *stack.Stack) (*Program, error) {
+	return e.CompileWithContext(input, st, "")
+}
+
+// CompileWithContext is like Compile. The path is the document path of the
+// expression, passed to the undefined policy.
+func (e *Evaluator) CompileWithContext(input string, st *stack.Stack, path string) (*Program, error) {
+	ex, err := e.expression(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve referenced variables defined in scope
+	env := make(map[string]any, len(ex.names))
+	var undefined []string
+	for _, name := range ex.names {
+		if val, ok := st.Lookup(name); ok {
+			env[name] = val
+			continue
+		}
+		undefined = append(undefined, name)
+	}
+
+	// Declare undefined variables for ?? defaults and the undefined policy
+	if len(undefined) > 0 && (ex.defaults || e.undefined != nil) {
+		variables, err := e.variables(input, ex)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range undefined {
+			if !variables[name] {
+				continue
+			}
+			var val any
+			if !ex.defaults {
+				val, err = e.UndefinedValue(Undefined{Name: name, Expr: input, Raw: name, Path: path})
+				if err != nil {
+					return nil, err
+				}
+			}
+			env[name] = val
+		}
+	}
+
+	var key strings.Builder
+	key.WriteString(input)
+	for _, name := range ex.names {
+		if _, ok := env[name]; ok {
+			key.WriteByte(0)
+			key.WriteString(name)
+		}
+	}
+
+	e.mu.RLock()
+	c, ok := e.programs[key.String()]
+	e.mu.RUnlock()
+
+	if !ok {
+		declared := make(types.Map, len(env))
+		for name := range env {
+			declared[name] = types.Any
+		}
+		program, err := e.compile(input, declared)
+		c = &compiled{program: program, err: err}
+
+		e.mu.Lock()
+		if len(e.programs) < programCacheLimit {
+			e.programs[key.String()] = c
+		}
+		e.mu.Unlock()
+	}
+
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &Program{program: c.program, env: env}, nil
+}
+
+// Eval compiles and evaluates an expression in the scope of the stack.
+func (e *Evaluator) Eval(input string, st *stack.Stack) (any, error) {
+	program, err := e.Compile(input, st)
+	if err != nil {
+		return nil, err
+	}
+	return program.Run()
+}
+
+// Precompile compiles an expression ahead of evaluation, assuming every
+// variable it references is defined. Evaluating the expression in a scope
+// defining those variables reuses the compiled program.
+// It returns an error if the expression is invalid.
+func (e *Evaluator) Precompile(input string) error {
+	ex, err := e.expression(input)
+	if err != nil {
+		return err
+	}
+	variables, err := e.variables(input, ex)
+	if err != nil {
+		return err
+	}
+
+	declared := make(types.Map, len(ex.names))
+	var key strings.Builder
+	key.WriteString(input)
+	for _, name := range ex.names {
+		if !variables[name] {
+			continue
+		}
+		declared[name] = types.Any
+		key.WriteByte(0)
+		key.WriteString(name)
+	}
+
+	e.mu.RLock()
+	_, ok := e.programs[key.String()]
+	e.mu.RUnlock()
+	if ok {
+		return nil
+	}
+
+	program, err := e.compile(input, declared)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	if len(e.programs) < programCacheLimit {
+		e.programs[key.String()] = &compiled{program: program}
+	}
+	e.mu.Unlock()
+
+	return nil
+}
+
+// UndefinedVariable returns the first variable referenced by an expression
+// that is not defined in the stack. Function names are not variables.
+// Expressions using the ?? operator have no undefined variables.
+func (e *Evaluator) UndefinedVariable(input string, st *stack.Stack) (string, bool) {
+	ex, err := e.expression(input)
+	if err != nil || ex.defaults {
+		return "", false
+	}
+
+	var undefined []string
+	for _, name := range ex.names {
+		if _, ok := st.Lookup(name); !ok {
+			undefined = append(undefined, name)
+		}
+	}
+	if len(undefined) == 0 {
+		return "", false
+	}
+
+	variables, err := e.variables(input, ex)
+	if err != nil {
+		return "", false
+	}
+	for _, name := range undefined {
+		if variables[name] {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Variables returns the variables referenced by an expression, with their
+// values in the stack. Functions and undefined variables are omitted.
+func (e *Evaluator) Variables(input string, st *stack.Stack) map[string]any {
+	ex, err := e.expression(input)
+	if err != nil {
+		return nil
+	}
+	variables, err := e.variables(input, ex)
+	if err != nil {
+		variables = nil
+	}
+
+	result := make(map[string]any, len(ex.names))
+	for _, name := range ex.names {
+		if variables != nil && !variables[name] {
+			continue
+		}
+		if val, ok := st.Lookup(name); ok {
+			result[name] = val
+		}
+	}
+	return result
+}
+
+// compile compiles an expression with the declared variables.
+func (e *Evaluator) compile(input string, declared types.Map) (*vm.Program, error) {
+	return expr.Compile(normalizePipes(input), e.options(expr.Env(declared), expr.Patch(defaultPatcher{}))...)
+}
+
+// options returns the compile options: base options, the cast functions
+// and the evaluator options, which may override cast functions.
+func (e *Evaluator) options(base ...expr.Option) []expr.Option {
+	opts := make([]expr.Option, 0, len(base)+len(castOptions)+len(e.opts))
+	opts = append(opts, base...)
+	opts = append(opts, castOptions...)
+	return append(opts, e.opts...)
+}
+
+// expression returns the cached analysis of an expression.
+func (e *Evaluator) expression(input string) (*expression, error) {
+	e.mu.RLock()
+	ex, ok := e.expressions[input]
+	e.mu.RUnlock()
+	if ok {
+		return ex, nil
+	}
+
+	tree, err := parser.Parse(normalizePipes(input))
+	if err != nil {
+		return nil, err
+	}
+
+	collector := &identCollector{seen: make(map[string]bool)}
+	ast.Walk(&tree.Node, collector)
+	sort.Strings(collector.names)
+
+	ex = &expression{
+		names:    collector.names,
+		defaults: collector.defaults,
+	}
+
+	e.mu.Lock()
+	if len(e.expressions) < programCacheLimit {
+		e.expressions[input] = ex
+	}
+	e.mu.Unlock()
+
+	return ex, nil
+}
+
+// variables returns the identifiers of an expression that are variables,
+// excluding functions and variables declared with let.
+func (e *Evaluator) variables(input string, ex *expression) (map[string]bool, error) {
+	ex.once.Do(func() {
+		// Compile once with undefined variables allowed, to tell variables from functions
+		probe, err := expr.Compile(normalizePipes(input), e.options(expr.Env(types.Map{}), expr.AllowUndefinedVariables())...)
+		if err != nil {
+			ex.err = err
+			return
+		}
+
+		locals := &funcCollector{seen: make(map[string]bool)}
+		node := probe.Node()
+		ast.Walk(&node, locals)
+
+		ex.variables = make(map[string]bool, len(ex.names))
+		for _, name := range ex.names {
+			if !locals.seen[name] {
+				ex.variables[name] = true
+			}
+		}
+	})
+	return ex.variables, ex.err
+}
+
+// identCollector collects unique identifier names from an expression tree.
+type identCollector struct {
+	seen     map[string]bool
+	names    []string
+	defaults bool
+}
+
+// Visit implements ast.Visitor.
+func (c *identCollector) Visit(node *ast.Node) {
+	switch n := (*node).(type) {
+	case *ast.IdentifierNode:
+		if !c.seen[n.Value] {
+			c.seen[n.Value] = true
+			c.names = append(c.names, n.Value)
+		}
+	case *ast.BinaryNode:
+		if n.Operator == "??" {
+			c.defaults = true
+		}
+	}
+}
+
+// funcCollector collects identifier names that resolve to functions,
+// and names of variables declared with let.
+type funcCollector struct {
+	seen map[string]bool
+}
+
+// Visit implements ast.Visitor.
+func (c *funcCollector) Visit(node *ast.Node) {
+	switch n := (*node).(type) {
+	case *ast.IdentifierNode:
+		if n.Nature().Func != nil {
+			c.seen[n.Value] = true
+		}
+	case *ast.VariableDeclaratorNode:
+		c.seen[n.Name] = true
+	}
+}
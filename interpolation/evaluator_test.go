@@ -0,0 +1,198 @@
+package interpolation_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/yamlexpr/interpolation"
+	"github.com/titpetric/yamlexpr/stack"
+)
+
+// TestEvaluator tests compiling and evaluating expressions against a stack.
+func TestEvaluator(t *testing.T) {
+	ev := interpolation.NewEvaluator()
+	st := stack.NewStack(map[string]any{
+		"count": 2,
+		"items": []any{"a", "b", "c"},
+	})
+
+	t.Run("evaluates in scope", func(t *testing.T) {
+		for i := range 3 {
+			st.Push(map[string]any{"item": i})
+			val, err := ev.Eval("item * count", st)
+			st.Pop()
+
+			require.NoError(t, err)
+			require.Equal(t, i*2, val)
+		}
+	})
+
+	t.Run("builtin shadowed by variable", func(t *testing.T) {
+		val, err := ev.Eval("count + len(items)", st)
+		require.NoError(t, err)
+		require.Equal(t, 5, val)
+
+		val, err = ev.Eval("count(items, # != 'b')", stack.NewStack(map[string]any{"items": []any{"a", "b"}}))
+		require.NoError(t, err)
+		require.Equal(t, 1, val)
+	})
+
+	t.Run("undefined variable", func(t *testing.T) {
+		_, err := ev.Compile("missing + 1", st)
+		require.Error(t, err)
+
+		st.Push(map[string]any{"missing": 1})
+		val, err := ev.Eval("missing + 1", st)
+		st.Pop()
+		require.NoError(t, err)
+		require.Equal(t, 2, val)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		_, err := ev.Eval("count +", st)
+		require.Error(t, err)
+	})
+
+	t.Run("precompile", func(t *testing.T) {
+		ev := interpolation.NewEvaluator()
+		require.NoError(t, ev.Precompile("upper(name) + suffix"))
+		require.Error(t, ev.Precompile("name +"))
+
+		st := stack.NewStack(map[string]any{"name": "app", "suffix": "-1"})
+		val, err := ev.Eval("upper(name) + suffix", st)
+		require.NoError(t, err)
+		require.Equal(t, "APP-1", val)
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		val, err := ev.Eval(`missing ?? upper("x")`, st)
+		require.NoError(t, err)
+		require.Equal(t, "X", val)
+
+		val, err = ev.Eval("config.port.number ?? count", st)
+		require.NoError(t, err)
+		require.Equal(t, 2, val)
+	})
+
+	t.Run("undefined variable name", func(t *testing.T) {
+		name, ok := ev.UndefinedVariable("upper(missing) + string(count)", st)
+		require.True(t, ok)
+		require.Equal(t, "missing", name)
+
+		_, ok = ev.UndefinedVariable("let x = count; x * 2", st)
+		require.False(t, ok)
+
+		_, ok = ev.UndefinedVariable("missing ?? 1", st)
+		require.False(t, ok)
+	})
+
+	t.Run("undefined policy", func(t *testing.T) {
+		ev := interpolation.NewEvaluator()
+		ev.SetUndefined(interpolation.UndefinedKeep)
+
+		// Placeholder text is null in expressions
+		val, err := ev.Eval("env == 'env'", st)
+		require.NoError(t, err)
+		require.Equal(t, false, val)
+
+		val, err = ev.InterpolateConditionWithContext("${env}", st, "")
+		require.NoError(t, err)
+		require.Nil(t, val)
+
+		var paths []string
+		ev.SetUndefined(func(u interpolation.Undefined) (any, error) {
+			paths = append(paths, u.Path)
+			return nil, nil
+		})
+		_, err = ev.CompileWithContext("env", st, "job.if")
+		require.NoError(t, err)
+		require.Equal(t, []string{"job.if"}, paths)
+		ev.SetUndefined(interpolation.UndefinedKeep)
+
+		str, err := ev.InterpolateStringWithContext(st, "${count}/${missing | upper}", "")
+		require.NoError(t, err)
+		require.Equal(t, "2/${missing | upper}", str)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		ev := interpolation.NewEvaluator(expr.Function("double", func(params ...any) (any, error) {
+			return params[0].(int) * 2, nil
+		}))
+		val, err := ev.Eval("double(count)", st)
+		require.NoError(t, err)
+		require.Equal(t, 4, val)
+		require.Len(t, ev.Options(), 1)
+	})
+}
+
+// TestEvaluator_Concurrent tests concurrent use of an evaluator.
+func TestEvaluator_Concurrent(t *testing.T) {
+	ev := interpolation.NewEvaluator()
+
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			st := stack.NewStack(map[string]any{"n": i})
+			for j := range 100 {
+				val, err := ev.InterpolateValueWithContext(fmt.Sprintf("${n + %d}", j%10), st, "")
+				require.NoError(t, err)
+				require.Equal(t, i+j%10, val)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// benchmarkStack returns a stack resembling a matrix job scope.
+func benchmarkStack() *stack.Stack {
+	root := map[string]any{}
+	for i := range 50 {
+		root[fmt.Sprintf("var%d", i)] = i
+	}
+	st := stack.NewStack(root)
+	st.Push(map[string]any{"os": "linux", "version": 14, "arch": "arm64"})
+	return st
+}
+
+// BenchmarkInterpolate_Snapshot compiles each expression against a full stack snapshot.
+func BenchmarkInterpolate_Snapshot(b *testing.B) {
+	st := benchmarkStack()
+	input := "test-${os}-${version + 1}-${arch | upper}"
+
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, segment := range interpolation.Scan(input) {
+			if !segment.Expr {
+				continue
+			}
+			env := st.All()
+			program, err := expr.Compile(segment.Text, expr.Env(env))
+			if err != nil {
+				// Pipes require parentheses without the evaluator
+				program, err = expr.Compile(segment.Text+"()", expr.Env(env))
+			}
+			require.NoError(b, err)
+			_, err = expr.Run(program, env)
+			require.NoError(b, err)
+		}
+	}
+}
+
+// BenchmarkInterpolate_Evaluator uses the evaluator program cache.
+func BenchmarkInterpolate_Evaluator(b *testing.B) {
+	st := benchmarkStack()
+	ev := interpolation.NewEvaluator()
+	input := "test-${os}-${version + 1}-${arch | upper}"
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_, err := ev.InterpolateStringWithContext(st, input, "")
+		require.NoError(b, err)
+	}
+}
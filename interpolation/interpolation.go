@@ -2,7 +2,6 @@ package interpolation
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 
 	"github.com/expr-lang/expr"
@@ -10,48 +9,86 @@ import (
 	"github.com/titpetric/yamlexpr/stack"
 )
 
-// interpolationPattern matches ${...} syntax in strings.
-var interpolationPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
-
-// ContainsInterpolation checks if a string contains interpolation patterns (${...}).
+// ContainsInterpolation checks if a string contains interpolation patterns (${...})
+// or escaped placeholders ($${), which are unescaped by interpolation.
 func ContainsInterpolation(s string) bool {
-	return strings.Contains(s, "${") && strings.Contains(s, "}")
+	return strings.Contains(s, "${")
 }
 
 // InterpolateStringWithContext replaces ${...} placeholders with values.
 // Supports both simple variable references (${varname}) and expressions (${item * 2}).
 // It returns an error if a referenced variable doesn't exist or cannot be converted to string.
 // The path parameter is used for error context information.
-func InterpolateStringWithContext(st *stack.Stack, s string, path string) (string, error) {
-	var result strings.Builder
-	lastIdx := 0
+func InterpolateStringWithContext(st *stack.Stack, s string, path string, opts ...expr.Option) (string, error) {
+	return evaluatorFor(opts).InterpolateStringWithContext(st, s, path)
+}
 
-	for _, match := range interpolationPattern.FindAllStringSubmatchIndex(s, -1) {
-		// match has: [fullMatchStart, fullMatchEnd, group1Start, group1End, ...]
-		matchStart, matchEnd := match[0], match[1]
-		var captureStart, captureEnd int
+// InterpolateValue interpolates a value (typically a string) using the given stack and path.
+// For strings with interpolation, evaluates expressions and returns native types when possible.
+// Non-string values are returned unchanged. This is a strict version that errors on undefined variables.
+func InterpolateValue(value any, st *stack.Stack, path string, opts ...expr.Option) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return evaluatorFor(opts).InterpolateValueWithContext(v, st, path)
+	default:
+		// Return non-string values unchanged
+		return value, nil
+	}
+}
 
-		// Check if we have capture groups
-		if len(match) >= 4 {
-			captureStart, captureEnd = match[2], match[3]
-		} else {
-			// No capture group, shouldn't happen with our pattern
+// InterpolateValueWithContext is like InterpolateValue but works with ExprContext.
+// For single interpolations, preserves the native type of the value.
+// For multiple interpolations or mixed text, returns a string.
+func InterpolateValueWithContext(s string, st *stack.Stack, path string, opts ...expr.Option) (any, error) {
+	return evaluatorFor(opts).InterpolateValueWithContext(s, st, path)
+}
+
+// InterpolateStringWithContext replaces ${...} placeholders with values.
+// See the package level InterpolateStringWithContext for details.
+func (e *Evaluator) InterpolateStringWithContext(st *stack.Stack, s string, path string) (string, error) {
+	return e.interpolateString(st, s, path, false)
+}
+
+// InterpolateConditionWithContext interpolates a condition like InterpolateValueWithContext.
+// Placeholders with undefined variables take the value of UndefinedValue, so
+// placeholder text kept by the undefined policy doesn't make conditions truthy.
+func (e *Evaluator) InterpolateConditionWithContext(s string, st *stack.Stack, path string) (any, error) {
+	return e.interpolateValue(s, st, path, true)
+}
+
+// interpolateString replaces ${...} placeholders with values.
+// With condition set, the undefined policy is applied with UndefinedValue.
+func (e *Evaluator) interpolateString(st *stack.Stack, s string, path string, condition bool) (string, error) {
+	var result strings.Builder
+
+	for _, segment := range Scan(s) {
+		// Add literal text as is
+		if !segment.Expr {
+			result.WriteString(segment.Text)
 			continue
 		}
 
-		// Add the string before this match
-		result.WriteString(s[lastIdx:matchStart])
+		exprStr := segment.Text
 
-		// Extract expression from capture group
-		exprStr := s[captureStart:captureEnd]
+		// Apply the undefined policy to placeholders with undefined variables
+		if val, ok, err := e.interpolateUndefined(segment, st, path, condition); ok {
+			if err != nil {
+				return "", err
+			}
+			if val == nil {
+				result.WriteString("null")
+			} else {
+				result.WriteString(fmt.Sprintf("%v", val))
+			}
+			continue
+		}
 
 		// Try to evaluate as an expression first using expr-lang
 		// This allows both simple variables and complex expressions like "item * 2"
-		env := st.All()
-		program, err := expr.Compile(exprStr, expr.Env(env))
+		program, err := e.CompileWithContext(exprStr, st, path)
 		if err == nil {
 			// Expression compiled successfully, evaluate it
-			val, err := expr.Run(program, env)
+			val, err := program.Run()
 			if err != nil {
 				pathCtx := ""
 				if path != "" {
@@ -68,92 +105,58 @@ func InterpolateStringWithContext(st *stack.Stack, s string, path string) (strin
 				result.WriteString(str)
 			}
 		} else {
-			// Expression compilation failed, try simple variable lookup (backwards compat)
+			pathCtx := ""
+			if path != "" {
+				pathCtx = fmt.Sprintf(" at %s", path)
+			}
+			if !IsPath(exprStr) {
+				return "", fmt.Errorf("error compiling expression '%s'%s: %w", exprStr, pathCtx, err)
+			}
+
+			// Variable paths that aren't valid expressions are resolved from the stack
 			val, ok := st.Resolve(exprStr)
 			if !ok || val == nil {
-				pathCtx := ""
-				if path != "" {
-					pathCtx = fmt.Sprintf(" at %s", path)
-				}
 				return "", fmt.Errorf("undefined variable '%s'%s", exprStr, pathCtx)
 			}
 
 			// Convert to string
 			str, ok := st.GetString(exprStr)
 			if !ok {
-				pathCtx := ""
-				if path != "" {
-					pathCtx = fmt.Sprintf(" at %s", path)
-				}
 				return "", fmt.Errorf("cannot convert variable '%s' to string%s", exprStr, pathCtx)
 			}
 
 			result.WriteString(str)
 		}
-
-		lastIdx = matchEnd
 	}
 
-	// Add remaining string
-	result.WriteString(s[lastIdx:])
 	return result.String(), nil
 }
 
-// InterpolateValue interpolates a value (typically a string) using the given stack and path.
-// For strings with interpolation, evaluates expressions and returns native types when possible.
-// Non-string values are returned unchanged. This is a strict version that errors on undefined variables.
-func InterpolateValue(value any, st *stack.Stack, path string) (any, error) {
-	switch v := value.(type) {
-	case string:
-		if ContainsInterpolation(v) {
-			// Try to interpolate as a single expression first
-			// This allows ${item * 2} to return an integer instead of a string
-			// Also preserves null values (${xcode} with xcode=null returns null, not string "null")
-			if isSingleInterpolation(v) {
-				exprStr := extractSingleExpression(v)
-				env := st.All()
-				program, err := expr.Compile(exprStr, expr.Env(env))
-				if err == nil {
-					// Expression compiled successfully, return the native type
-					result, err := expr.Run(program, env)
-					if err != nil {
-						pathCtx := ""
-						if path != "" {
-							pathCtx = fmt.Sprintf(" at %s", path)
-						}
-						return nil, fmt.Errorf("error evaluating expression '%s'%s: %w", exprStr, pathCtx, err)
-					}
-					// Return native type, including nil for null values
-					return result, nil
-				}
-			}
-			// Fall back to string interpolation
-			return InterpolateStringWithContext(st, v, path)
-		}
-		return v, nil
-	default:
-		// Return non-string values unchanged
-		return value, nil
-	}
+// InterpolateValueWithContext interpolates a string, preserving the native type of single interpolations.
+// See the package level InterpolateValueWithContext for details.
+func (e *Evaluator) InterpolateValueWithContext(s string, st *stack.Stack, path string) (any, error) {
+	return e.interpolateValue(s, st, path, false)
 }
 
-// InterpolateValueWithContext is like InterpolateValue but works with ExprContext.
-// For single interpolations, preserves the native type of the value.
-// For multiple interpolations or mixed text, returns a string.
-func InterpolateValueWithContext(s string, st *stack.Stack, path string) (any, error) {
+// interpolateValue interpolates a string, preserving the native type of single interpolations.
+// With condition set, the undefined policy is applied with UndefinedValue.
+func (e *Evaluator) interpolateValue(s string, st *stack.Stack, path string, condition bool) (any, error) {
 	if !ContainsInterpolation(s) {
 		return s, nil
 	}
 
 	// Try to interpolate as a single expression/variable first
 	// Also preserves null values (${xcode} with xcode=null returns null, not string "null")
-	if isSingleInterpolation(s) {
-		exprStr := extractSingleExpression(s)
-		env := st.All()
-		program, err := expr.Compile(exprStr, expr.Env(env))
+	if segments := Scan(s); len(segments) == 1 && segments[0].Expr {
+		if val, ok, err := e.interpolateUndefined(segments[0], st, path, condition); ok {
+			return val, err
+		}
+
+		exprStr := segments[0].Text
+		program, err := e.CompileWithContext(exprStr, st, path)
 		if err == nil {
 			// Expression compiled successfully, return the native type
-			result, err := expr.Run(program, env)
+			result, err := program.Run()
 			if err != nil {
 				pathCtx := ""
 				if path != "" {
@@ -167,55 +170,51 @@ func InterpolateValueWithContext(s string, st *stack.Stack, path string) (any, e
 	}
 
 	// Fall back to string interpolation
-	return InterpolateStringWithContext(st, s, path)
+	return e.interpolateString(st, s, path, condition)
 }
 
-// isSingleInterpolation checks if a string contains exactly one ${...} expression
-func isSingleInterpolation(s string) bool {
-	matches := interpolationPattern.FindAllStringIndex(s, -1)
-	if len(matches) != 1 {
-		return false
+// interpolateUndefined applies the undefined policy to a placeholder referencing
+// an undefined variable. It returns false if no policy is set, or if all the
+// variables referenced by the placeholder are defined.
+func (e *Evaluator) interpolateUndefined(segment Segment, st *stack.Stack, path string, condition bool) (any, bool, error) {
+	if e.undefined == nil {
+		return nil, false, nil
 	}
-	// Check if the expression spans the entire string (no text before or after)
-	match := matches[0]
-	return match[0] == 0 && match[1] == len(s)
-}
-
-// extractSingleExpression extracts the expression from a ${...} pattern
-func extractSingleExpression(s string) string {
-	matches := interpolationPattern.FindAllStringSubmatchIndex(s, -1)
-	if len(matches) == 0 || len(matches[0]) < 4 {
-		return ""
+	name, ok := e.UndefinedVariable(segment.Text, st)
+	if !ok {
+		return nil, false, nil
+	}
+	u := Undefined{
+		Name: name,
+		Expr: segment.Text,
+		Raw:  segment.Raw,
+		Path: path,
 	}
-	captureStart, captureEnd := matches[0][2], matches[0][3]
-	return s[captureStart:captureEnd]
+	if condition {
+		val, err := e.UndefinedValue(u)
+		return val, true, err
+	}
+	val, err := e.undefined(u)
+	return val, true, err
 }
 
 // InterpolateStringPermissive replaces ${varname} placeholders with stack values.
 // If a variable is undefined or nil, the entire interpolated string returns nil.
-// This is useful for optional matrix dimensions that may not be set.
+// Expressions aren't evaluated.
+//
+// Deprecated: Use an Evaluator with an undefined policy set with
+// Evaluator.SetUndefined, e.g. UndefinedNull, and Evaluator.InterpolateValueWithContext.
 func InterpolateStringPermissive(s string, st *stack.Stack) (any, error) {
 	var result strings.Builder
-	lastIdx := 0
-
-	for _, match := range interpolationPattern.FindAllStringSubmatchIndex(s, -1) {
-		// match has: [fullMatchStart, fullMatchEnd, group1Start, group1End, ...]
-		matchStart, matchEnd := match[0], match[1]
-		var captureStart, captureEnd int
 
-		// Check if we have capture groups
-		if len(match) >= 4 {
-			captureStart, captureEnd = match[2], match[3]
-		} else {
-			// No capture group, shouldn't happen with our pattern
+	for _, segment := range Scan(s) {
+		// Add literal text as is
+		if !segment.Expr {
+			result.WriteString(segment.Text)
 			continue
 		}
 
-		// Add the string before this match
-		result.WriteString(s[lastIdx:matchStart])
-
-		// Extract variable name from capture group
-		varName := s[captureStart:captureEnd]
+		varName := segment.Text
 
 		// Resolve from stack - if undefined or nil, return nil for entire interpolation
 		val, ok := st.Resolve(varName)
@@ -230,17 +229,17 @@ func InterpolateStringPermissive(s string, st *stack.Stack) (any, error) {
 		}
 
 		result.WriteString(str)
-		lastIdx = matchEnd
 	}
 
-	// Add remaining string
-	result.WriteString(s[lastIdx:])
 	return result.String(), nil
 }
 
 // InterpolateValuePermissive interpolates a value with permissive handling of undefined variables.
 // Strings with interpolation return nil if any referenced variable is undefined.
 // Non-string values are returned unchanged.
+//
+// Deprecated: Use an Evaluator with an undefined policy set with
+// Evaluator.SetUndefined, e.g. UndefinedNull, and Evaluator.InterpolateValueWithContext.
 func InterpolateValuePermissive(value any, st *stack.Stack) (any, error) {
 	switch v := value.(type) {
 	case string:
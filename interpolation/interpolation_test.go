@@ -20,7 +20,8 @@ func TestContainsInterpolation(t *testing.T) {
 		{"no interpolation", "hello world", false},
 		{"with interpolation", "hello ${name}", true},
 		{"multiple interpolations", "${first} ${second}", true},
-		{"only opening bracket", "hello ${name", false},
+		{"only opening bracket", "hello ${name", true}, // Unclosed placeholders are kept as text
+		{"escaped", "price $${", true},
 		{"only closing bracket", "hello name}", false},
 		{"just brackets", "${}", true}, // Contains both ${ and }, technically
 	}
@@ -96,6 +97,35 @@ func TestInterpolateStringWithContext(t *testing.T) {
 	}
 }
 
+// TestInterpolateStringWithContext_CompileErrors tests errors of expressions failing to compile.
+func TestInterpolateStringWithContext_CompileErrors(t *testing.T) {
+	st := stack.NewStack(map[string]any{
+		"n":     "app",
+		"items": []any{"a"},
+	})
+
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"${ n | uper }", "error compiling expression 'n | uper' at spec.name: unknown name uper"},
+		{`${ missing | default("z") }`, `error compiling expression 'missing | default("z")' at spec.name: unknown name default`},
+		{"x-${ missing.name }", "undefined variable 'missing.name' at spec.name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := interpolation.InterpolateValueWithContext(tt.input, st, "spec.name")
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	// Paths that aren't valid expressions are resolved from the stack
+	val, err := interpolation.InterpolateValueWithContext("${items.0}", st, "")
+	require.NoError(t, err)
+	require.Equal(t, "a", val)
+}
+
 // TestInterpolateValue tests interpolation of various value types.
 func TestInterpolateValue(t *testing.T) {
 	st := stack.NewStack(map[string]any{
@@ -214,3 +244,112 @@ func TestInterpolateValuePermissive(t *testing.T) {
 		})
 	}
 }
+
+// TestInterpolateValueWithContext_Pipes tests pipe filter syntax.
+func TestInterpolateValueWithContext_Pipes(t *testing.T) {
+	st := stack.NewStack(map[string]any{
+		"name":  "  Hello World  ",
+		"tags":  []any{"b", "a"},
+		"count": 3,
+	})
+
+	tests := []struct {
+		name     string
+		input    string
+		expected any
+	}{
+		{"bare filter", "${ name | trim }", "Hello World"},
+		{"chained filters", "${ name | trim | upper }", "HELLO WORLD"},
+		{"filter with arguments", "${ name | trim | split(\" \") | join(\"-\") }", "Hello-World"},
+		{"called filter", "${ tags | sort() | first() }", "a"},
+		{"logical or is kept", "${ count > 5 || count == 3 }", true},
+		{"pipe in string literal", `${ "a|b" | upper }`, "A|B"},
+		{"mixed text", "name=${ name | trim | lower }!", "name=hello world!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := interpolation.InterpolateValueWithContext(tt.input, st, "")
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+// TestScan tests splitting strings into text and expression segments.
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []interpolation.Segment
+	}{
+		{"empty string", "", nil},
+		{"plain text", "hello", []interpolation.Segment{
+			{Text: "hello", Raw: "hello"},
+		}},
+		{"expression", "a ${ name } b", []interpolation.Segment{
+			{Text: "a ", Raw: "a "},
+			{Text: "name", Raw: "${ name }", Expr: true},
+			{Text: " b", Raw: " b"},
+		}},
+		{"nested braces", `${ {"a": {"b": 1}}.a.b }`, []interpolation.Segment{
+			{Text: `{"a": {"b": 1}}.a.b`, Raw: `${ {"a": {"b": 1}}.a.b }`, Expr: true},
+		}},
+		{"brace in string literal", `${ x ?? "}" }!`, []interpolation.Segment{
+			{Text: `x ?? "}"`, Raw: `${ x ?? "}" }`, Expr: true},
+			{Text: "!", Raw: "!"},
+		}},
+		{"escaped", "$${{ github.sha }}", []interpolation.Segment{
+			{Text: "${{ github.sha }}", Raw: "$${{ github.sha }}"},
+		}},
+		{"unterminated", "${name", []interpolation.Segment{
+			{Text: "${name", Raw: "${name"},
+		}},
+		{"empty expression", "${}", []interpolation.Segment{
+			{Text: "${}", Raw: "${}"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, interpolation.Scan(tt.input))
+		})
+	}
+}
+
+// TestInterpolateValueWithContext_Tokens tests nested braces and escaping.
+func TestInterpolateValueWithContext_Tokens(t *testing.T) {
+	st := stack.NewStack(map[string]any{
+		"name":  "app",
+		"empty": nil,
+	})
+
+	tests := []struct {
+		name     string
+		input    string
+		expected any
+	}{
+		{"map literal", `${ {"a": 1}.a }`, 1},
+		{"brace in string literal", `${ empty ?? "}" }`, "}"},
+		{"brace in string literal with text", `name=${ name + "{}" }`, "name=app{}"},
+		{"escaped expression", "$${name}", "${name}"},
+		{"escaped github actions", "run: $${{ github.sha }} ${name}", "run: ${{ github.sha }} app"},
+		{"escaped shell variable", "echo $${HOME}/${name}", "echo ${HOME}/app"},
+		{"escaped without closing brace", "price $${", "price ${"},
+		{"unclosed placeholder", "price ${", "price ${"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := interpolation.InterpolateValueWithContext(tt.input, st, "")
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+
+	t.Run("lenient", func(t *testing.T) {
+		result, err := interpolation.InterpolateString(st, "$${name} ${name} ${ {\"x\": 1} }")
+		require.NoError(t, err)
+		require.Equal(t, `${name} app ${ {"x": 1} }`, result)
+	})
+}
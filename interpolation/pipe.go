@@ -0,0 +1,102 @@
+package interpolation
+
+import (
+	"strings"
+)
+
+// normalizePipes rewrites bare filter names after a pipe into function calls,
+// so `name | upper | trunc(63)` compiles as `name | upper() | trunc(63)`.
+// The logical or operator (||) and string literals are left untouched.
+func normalizePipes(input string) string {
+	if !strings.Contains(input, "|") {
+		return input
+	}
+
+	var b strings.Builder
+	b.Grow(len(input) + 8)
+
+	for i := 0; i < len(input); i++ {
+		ch := input[i]
+
+		// Copy string literals verbatim
+		if ch == '"' || ch == '\'' || ch == '`' {
+			end := skipString(input, i)
+			b.WriteString(input[i:end])
+			i = end - 1
+			continue
+		}
+
+		b.WriteByte(ch)
+		if ch != '|' {
+			continue
+		}
+
+		// Skip the logical or operator
+		if i+1 < len(input) && input[i+1] == '|' {
+			b.WriteByte('|')
+			i++
+			continue
+		}
+
+		// Copy whitespace and the filter identifier
+		j := i + 1
+		for j < len(input) && isSpace(input[j]) {
+			j++
+		}
+		k := j
+		for k < len(input) && isIdentChar(input[k], k == j) {
+			k++
+		}
+		b.WriteString(input[i+1 : k])
+		i = k - 1
+
+		if k == j {
+			continue
+		}
+
+		// Add call parentheses unless the filter is already called
+		n := k
+		for n < len(input) && isSpace(input[n]) {
+			n++
+		}
+		if n >= len(input) || input[n] != '(' {
+			b.WriteString("()")
+		}
+	}
+
+	return b.String()
+}
+
+// skipString returns the index after the string literal starting at start.
+// Unterminated literals extend to the end of the input.
+func skipString(input string, start int) int {
+	quote := input[start]
+	for i := start + 1; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i + 1
+		}
+	}
+	return len(input)
+}
+
+// isSpace returns true for whitespace characters.
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
+// isIdentChar returns true for characters valid in an identifier.
+// Digits are not valid as the first character.
+func isIdentChar(ch byte, first bool) bool {
+	switch {
+	case ch == '_', ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z':
+		return true
+	case ch >= '0' && ch <= '9':
+		return !first
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package interpolation
+
+import (
+	"regexp"
+	"strings"
+)
+
+// pathPattern matches variable paths like `items.0` or `matrix[os]`.
+var pathPattern = regexp.MustCompile(`^[\w.\[\]-]+$`)
+
+// IsPath reports whether an expression is a variable path. Paths that aren't
+// valid expressions, e.g. `${items.0}`, are resolved from the stack.
+func IsPath(s string) bool {
+	return pathPattern.MatchString(s)
+}
+
+// Segment is a part of a string split into literal text and ${...} expressions.
+type Segment struct {
+	// Text is the literal text, or the expression source for expression segments.
+	Text string
+	// Raw is the original source of the segment, e.g. "${ name }" for an expression.
+	Raw string
+	// Expr is true if the segment is an ${...} expression.
+	Expr bool
+}
+
+// Scan splits a string into literal text and ${...} expression segments.
+//
+// Expressions may contain nested braces (map literals) and string literals
+// containing braces, e.g. `${ {"a": 1}.a }` or `${ x ?? "}" }`.
+// The sequence `$${` is an escape that produces a literal `${`, so
+// `$${{ github.sha }}` outputs `${{ github.sha }}`.
+// Unterminated and empty expressions are kept as literal text.
+func Scan(s string) []Segment {
+	var segments []Segment
+	var text, raw strings.Builder
+
+	flush := func() {
+		if raw.Len() > 0 {
+			segments = append(segments, Segment{Text: text.String(), Raw: raw.String()})
+			text.Reset()
+			raw.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "$${") {
+			text.WriteString("${")
+			raw.WriteString("$${")
+			i += 3
+			continue
+		}
+		if strings.HasPrefix(s[i:], "${") {
+			end := scanExpression(s, i+2)
+			if end >= 0 && strings.TrimSpace(s[i+2:end]) != "" {
+				flush()
+				segments = append(segments, Segment{
+					Text: strings.TrimSpace(s[i+2 : end]),
+					Raw:  s[i : end+1],
+					Expr: true,
+				})
+				i = end + 1
+				continue
+			}
+		}
+		text.WriteByte(s[i])
+		raw.WriteByte(s[i])
+		i++
+	}
+	flush()
+
+	return segments
+}
+
+// scanExpression returns the index of the brace closing an expression starting at start.
+// Braces are balanced and string literals are skipped. Returns -1 if the expression is unterminated.
+func scanExpression(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'', '`':
+			i = skipString(s, i) - 1
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
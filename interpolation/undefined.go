@@ -0,0 +1,79 @@
+package interpolation
+
+import (
+	"fmt"
+
+	"github.com/expr-lang/expr/ast"
+)
+
+// Undefined describes a reference to an undefined variable.
+type Undefined struct {
+	// Name is the undefined variable name.
+	Name string
+	// Expr is the expression referencing the variable.
+	Expr string
+	// Raw is the source of the placeholder, e.g. "${ name }".
+	// For expressions, conditions and for sources, Raw is the variable name.
+	Raw string
+	// Path is the document path of the value, if known.
+	Path string
+}
+
+// UndefinedFunc decides the value used for an undefined variable.
+//
+// For interpolations, the returned value replaces the whole placeholder.
+// For expressions, conditions and for sources, the returned value is used as
+// the variable value, and placeholder text (Raw) is null, see
+// Evaluator.UndefinedValue. Returning an error stops processing.
+type UndefinedFunc func(u Undefined) (any, error)
+
+// UndefinedError is an UndefinedFunc reporting undefined variables as errors.
+func UndefinedError(u Undefined) (any, error) {
+	pathCtx := ""
+	if u.Path != "" {
+		pathCtx = fmt.Sprintf(" at %s", u.Path)
+	}
+	return nil, fmt.Errorf("undefined variable '%s'%s", u.Name, pathCtx)
+}
+
+// UndefinedEmpty is an UndefinedFunc replacing undefined variables with an empty string.
+func UndefinedEmpty(Undefined) (any, error) {
+	return "", nil
+}
+
+// UndefinedKeep is an UndefinedFunc keeping placeholders with undefined variables as literal text.
+// In expressions, conditions and for sources, undefined variables are null.
+func UndefinedKeep(u Undefined) (any, error) {
+	return u.Raw, nil
+}
+
+// UndefinedNull is an UndefinedFunc replacing undefined variables with null.
+func UndefinedNull(Undefined) (any, error) {
+	return nil, nil
+}
+
+// defaultPatcher makes member access on the left side of the ?? operator optional,
+// so `${ config.port ?? 8080 }` works when config is undefined.
+type defaultPatcher struct{}
+
+// Visit implements ast.Visitor.
+func (defaultPatcher) Visit(node *ast.Node) {
+	binary, ok := (*node).(*ast.BinaryNode)
+	if !ok || binary.Operator != "??" {
+		return
+	}
+
+	optional := false
+	for n := binary.Left; ; {
+		member, ok := n.(*ast.MemberNode)
+		if !ok {
+			break
+		}
+		member.Optional = true
+		optional = true
+		n = member.Node
+	}
+	if _, isChain := binary.Left.(*ast.ChainNode); optional && !isChain {
+		ast.Patch(&binary.Left, &ast.ChainNode{Node: binary.Left})
+	}
+}
@@ -0,0 +1,608 @@
+package yamlexpr
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/titpetric/yamlexpr/interpolation"
+	"github.com/titpetric/yamlexpr/stack"
+)
+
+// Severity is the severity of a Diagnostic.
+type Severity string
+
+// Diagnostic severities.
+const (
+	// SeverityError reports problems failing or breaking rendering.
+	SeverityError Severity = "error"
+	// SeverityWarning reports likely mistakes.
+	SeverityWarning Severity = "warning"
+)
+
+// Lint rules, see LintRules.
+const (
+	RuleInvalidFor        = "invalid-for"
+	RuleInvalidExpression = "invalid-expression"
+	RuleInvalidDirective  = "invalid-directive"
+	RuleInvalidInclude    = "invalid-include"
+	RuleMissingInclude    = "missing-include"
+	RuleUndefinedVariable = "undefined-variable"
+	RuleUnknownDirective  = "unknown-directive"
+	RuleUnusedInput       = "unused-input"
+	RuleEmptyMatrix       = "empty-matrix"
+	RuleSyntaxConflict    = "syntax-conflict"
+)
+
+// LintRules describes the rules reported by Lint.
+var LintRules = map[string]string{
+	RuleInvalidFor:        "for expression can't be parsed",
+	RuleInvalidExpression: "expression or condition fails to compile",
+	RuleInvalidDirective:  "directive or inputs declaration is malformed",
+	RuleInvalidInclude:    "include is malformed, cyclic or not valid YAML",
+	RuleMissingInclude:    "included file doesn't exist",
+	RuleUndefinedVariable: "variable isn't defined in any scope",
+	RuleUnknownDirective:  "key looks like a misspelled directive",
+	RuleUnusedInput:       "declared input is never used",
+	RuleEmptyMatrix:       "matrix produces no jobs",
+	RuleSyntaxConflict:    "custom syntax uses a keyword for more than one directive",
+}
+
+// Diagnostic is a problem found by Lint.
+type Diagnostic struct {
+	// Rule is the lint rule reporting the problem, see LintRules.
+	Rule string `json:"rule" yaml:"rule"`
+	// Severity is the severity of the problem.
+	Severity Severity `json:"severity" yaml:"severity"`
+	// Message describes the problem.
+	Message string `json:"message" yaml:"message"`
+	// File is the template file, empty for configuration problems.
+	File string `json:"file,omitempty" yaml:"file,omitempty"`
+	// Line is the line of the problem in the file.
+	Line int `json:"line,omitempty" yaml:"line,omitempty"`
+	// Column is the column of the problem in the file.
+	Column int `json:"column,omitempty" yaml:"column,omitempty"`
+	// Path is the document path of the problem, as used in errors.
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+}
+
+// String returns the diagnostic as `file:line:column: severity: message (rule)`.
+func (d Diagnostic) String() string {
+	if d.File == "" {
+		return fmt.Sprintf("%s: %s (%s)", d.Severity, d.Message, d.Rule)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s (%s)", d.File, d.Line, d.Column, d.Severity, d.Message, d.Rule)
+}
+
+// Lint checks a template file without rendering it, following the includes
+// it references. It reports malformed for expressions, expressions that fail
+// to compile, variables not defined in any scope, misspelled directives,
+// missing includes, unused inputs, matrices producing no jobs and
+// conflicting custom syntax keywords. Diagnostics are sorted by file, line
+// and column.
+//
+// Names in vars are defined variables, as if passed to Template.Execute.
+// It returns an error if the file can't be read or parsed.
+func (e *Expr) Lint(filename string, vars map[string]any) ([]Diagnostic, error) {
+	node, doc, err := e.readDocument(filename)
+	if err != nil {
+		return nil, err
+	}
+	return e.lint(filename, node, doc, vars), nil
+}
+
+// LintYAML checks YAML data like Lint. The filename is used in diagnostics.
+func (e *Expr) LintYAML(filename string, data []byte, vars map[string]any) ([]Diagnostic, error) {
+	node, doc, err := e.decodeDocument(filename, data)
+	if err != nil {
+		return nil, err
+	}
+	return e.lint(filename, node, doc, vars), nil
+}
+
+// linter collects the diagnostics of a template.
+type linter struct {
+	e        *Expr
+	filename string
+
+	diagnostics []Diagnostic
+	// seen holds reported locations, as included files are linted at every include site
+	seen map[string]bool
+	// used holds the names of referenced variables
+	used map[string]bool
+	// dynamic is non-zero while variables are defined by values unknown before rendering
+	dynamic int
+}
+
+// lint checks a parsed template.
+func (e *Expr) lint(filename string, node *yaml.Node, doc map[string]any, vars map[string]any) []Diagnostic {
+	l := &linter{
+		e:        e,
+		filename: filename,
+		seen:     make(map[string]bool),
+		used:     make(map[string]bool),
+	}
+	l.lintSyntax()
+
+	root := contentNode(node)
+	inputsKey := e.config.InputsDirective()
+	keyNode, inputsNode := mappingEntry(root, inputsKey)
+
+	inputs, err := e.parseInputs(doc[inputsKey], node)
+	if err != nil {
+		l.report(nil, keyNode, RuleInvalidDirective, SeverityError, inputsKey, err.Error())
+	}
+
+	// Values are placeholders, only the names are checked
+	rootVars := make(map[string]any, len(doc))
+	for k := range doc {
+		if k != inputsKey {
+			rootVars[k] = nil
+		}
+	}
+	scope := make(map[string]any, len(inputs)+len(vars))
+	for _, input := range inputs {
+		scope[input.Name] = nil
+	}
+	for k := range vars {
+		scope[k] = nil
+	}
+	st := stack.NewStack(rootVars)
+	st.Push(scope)
+
+	ctx := NewContext(&ContextOptions{
+		Stack:     st,
+		Evaluator: e.evaluator,
+	})
+	l.lintMap(ctx, withoutNodeKey(root, inputsKey))
+
+	if err == nil && inputsNode != nil && inputsNode.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(inputsNode.Content); i += 2 {
+			name := inputsNode.Content[i].Value
+			if !l.used[name] {
+				path := joinPath(inputsKey, name)
+				l.report(nil, inputsNode.Content[i], RuleUnusedInput, SeverityWarning, path, fmt.Sprintf("input '%s' is never used at %s", name, path))
+			}
+		}
+	}
+
+	slices.SortStableFunc(l.diagnostics, func(a, b Diagnostic) int {
+		return cmp.Or(
+			cmp.Compare(a.File, b.File),
+			cmp.Compare(a.Line, b.Line),
+			cmp.Compare(a.Column, b.Column),
+		)
+	})
+	return l.diagnostics
+}
+
+// lintSyntax reports directives sharing a keyword.
+func (l *linter) lintSyntax() {
+	directives := []struct{ name, keyword string }{
+		{"if", l.e.config.IfDirective()},
+		{"for", l.e.config.ForDirective()},
+		{"include", l.e.config.IncludeDirective()},
+		{"matrix", l.e.config.MatrixDirective()},
+		{"inputs", l.e.config.InputsDirective()},
+	}
+	for i, d := range directives {
+		for _, other := range directives[:i] {
+			if d.keyword == other.keyword {
+				l.report(nil, nil, RuleSyntaxConflict, SeverityError, "", fmt.Sprintf("syntax keyword '%s' is used for both %s and %s", d.keyword, other.name, d.name))
+			}
+		}
+	}
+}
+
+// report adds a diagnostic at node in the file processed with ctx.
+// Diagnostics at a reported location are skipped.
+func (l *linter) report(ctx *Context, node *yaml.Node, rule string, severity Severity, path, message string) {
+	d := Diagnostic{
+		Rule:     rule,
+		Severity: severity,
+		Message:  message,
+		Path:     path,
+	}
+	if node != nil {
+		d.File = l.filename
+		if ctx != nil && len(ctx.IncludeChain()) > 0 {
+			d.File = ctx.IncludeChain()[len(ctx.IncludeChain())-1]
+		}
+		d.Line, d.Column = node.Line, node.Column
+
+		key := fmt.Sprintf("%s:%s:%d:%d", rule, d.File, d.Line, d.Column)
+		if l.seen[key] {
+			return
+		}
+		l.seen[key] = true
+	}
+	l.diagnostics = append(l.diagnostics, d)
+}
+
+// lintValue checks a value, following processWithContext.
+func (l *linter) lintValue(ctx *Context, node *yaml.Node) {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		l.lintMap(ctx, node)
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			l.lintItem(ctx.AppendPath(fmt.Sprintf("[%d]", i)), resolveAlias(item))
+		}
+	case yaml.ScalarNode:
+		if node.ShortTag() == "!!str" {
+			l.lintString(ctx, node, node.Value, ctx.Path())
+		}
+	}
+}
+
+// lintItem checks a sequence item, following processSliceWithContext.
+// Matrix and for directives of items are expanded before includes.
+func (l *linter) lintItem(ctx *Context, node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		if _, valueNode := mappingEntry(node, l.e.config.MatrixDirective()); valueNode != nil {
+			l.lintMatrix(ctx, node)
+			return
+		}
+		if _, valueNode := mappingEntry(node, l.e.config.ForDirective()); valueNode != nil {
+			l.lintFor(ctx, node)
+			return
+		}
+	}
+	l.lintValue(ctx, node)
+}
+
+// lintMap checks a map and its directives, following processMapWithContext.
+func (l *linter) lintMap(ctx *Context, node *yaml.Node) {
+	l.lintKeys(ctx, node)
+
+	if keyNode, valueNode := mappingEntry(node, l.e.config.IncludeDirective()); valueNode != nil {
+		l.lintInclude(ctx, keyNode, valueNode)
+		node = withoutNodeKey(node, l.e.config.IncludeDirective())
+	}
+	if _, valueNode := mappingEntry(node, l.e.config.MatrixDirective()); valueNode != nil {
+		l.lintMatrix(ctx, node)
+		return
+	}
+	if _, valueNode := mappingEntry(node, l.e.config.ForDirective()); valueNode != nil {
+		l.lintFor(ctx, node)
+		return
+	}
+	if _, valueNode := mappingEntry(node, l.e.config.IfDirective()); valueNode != nil {
+		l.lintCondition(ctx, valueNode)
+		node = withoutNodeKey(node, l.e.config.IfDirective())
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		l.lintValue(ctx.AppendPath(node.Content[i].Value), node.Content[i+1])
+	}
+}
+
+// lintKeys reports keys that look like misspelled directives.
+// They are errors in strict mode.
+func (l *linter) lintKeys(ctx *Context, node *yaml.Node) {
+	severity := SeverityWarning
+	if l.e.config.Strict {
+		severity = SeverityError
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		if directive, ok := l.e.misspelledDirective(key, ctx.Path() == ""); ok {
+			path := joinPath(ctx.Path(), key)
+			l.report(ctx, node.Content[i], RuleUnknownDirective, severity, path, fmt.Sprintf("unknown key '%s' at %s, did you mean '%s'?", key, path, directive))
+		}
+	}
+}
+
+// lintInclude checks the included files in the scope of the include site.
+// Keys of included maps are defined after the include, as when rendering.
+func (l *linter) lintInclude(ctx *Context, keyNode, valueNode *yaml.Node) {
+	path := joinPath(ctx.Path(), keyNode.Value)
+
+	var incl any
+	if err := valueNode.Decode(&incl); err != nil {
+		l.report(ctx, valueNode, RuleInvalidInclude, SeverityError, path, fmt.Sprintf("%v at %s", err, path))
+		return
+	}
+	if err := compileInclude(incl, path); err != nil {
+		l.report(ctx, valueNode, RuleInvalidInclude, SeverityError, path, err.Error())
+		return
+	}
+
+	fileNodes := []*yaml.Node{valueNode}
+	if valueNode.Kind == yaml.SequenceNode {
+		fileNodes = valueNode.Content
+	}
+	for _, fileNode := range fileNodes {
+		filename := fileNode.Value
+		chain := append([]string{l.filename}, ctx.IncludeChain()...)
+		if slices.Contains(chain, filename) {
+			l.report(ctx, fileNode, RuleInvalidInclude, SeverityError, path, fmt.Sprintf("include cycle %s -> %s at %s", strings.Join(chain, " -> "), filename, path))
+			continue
+		}
+		if l.e.fs == nil {
+			l.report(ctx, fileNode, RuleMissingInclude, SeverityError, path, fmt.Sprintf("error including %s: no filesystem configured at %s", filename, path))
+			continue
+		}
+
+		data, err := fs.ReadFile(l.e.fs, filename)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				l.report(ctx, fileNode, RuleMissingInclude, SeverityError, path, fmt.Sprintf("included file %s does not exist at %s", filename, path))
+			} else {
+				l.report(ctx, fileNode, RuleMissingInclude, SeverityError, path, fmt.Sprintf("error reading file %s at %s: %v", filename, path, err))
+			}
+			continue
+		}
+		node, included, err := l.e.decodeYAML(data)
+		if err != nil {
+			l.report(ctx, fileNode, RuleInvalidInclude, SeverityError, path, fmt.Sprintf("error parsing YAML file %s at %s: %v", filename, path, err))
+			continue
+		}
+
+		l.lintValue(ctx.WithInclude(filename), contentNode(node))
+
+		if m, ok := included.(map[string]any); ok {
+			for k := range m {
+				if !l.isDirective(k) {
+					ctx.Stack().Set(k, nil)
+				}
+			}
+		}
+	}
+}
+
+// lintMatrix checks a matrix directive, and the template in the scope of its jobs.
+func (l *linter) lintMatrix(ctx *Context, node *yaml.Node) {
+	keyNode, valueNode := mappingEntry(node, l.e.config.MatrixDirective())
+	valueNode = resolveAlias(valueNode)
+	path := joinPath(ctx.Path(), keyNode.Value)
+	template := withoutNodeKey(node, keyNode.Value)
+
+	scope := make(map[string]any)
+	if !l.e.config.Strict {
+		// Template keys default to null in matrix jobs
+		for i := 0; i+1 < len(template.Content); i += 2 {
+			scope[template.Content[i].Value] = nil
+		}
+	}
+
+	dynamic := true
+	switch {
+	case valueNode.Kind == yaml.ScalarNode && valueNode.ShortTag() == "!!str" && interpolation.ContainsInterpolation(valueNode.Value):
+		l.lintString(ctx, valueNode, valueNode.Value, path)
+	case valueNode.Kind == yaml.MappingNode:
+		var m map[string]any
+		if err := valueNode.Decode(&m); err != nil {
+			l.report(ctx, valueNode, RuleInvalidDirective, SeverityError, path, fmt.Sprintf("error parsing matrix at %s: %v", path, err))
+			break
+		}
+		md, err := parseMatrixDirective(m)
+		if err != nil {
+			l.report(ctx, valueNode, RuleInvalidDirective, SeverityError, path, fmt.Sprintf("error parsing matrix at %s: %v", path, err))
+			break
+		}
+		// Interpolated dimensions only change values, the names are known
+		dynamic = false
+
+		interpolated := false
+		for i := 0; i+1 < len(valueNode.Content); i += 2 {
+			item := resolveAlias(valueNode.Content[i+1])
+			if item.Kind == yaml.ScalarNode && item.ShortTag() == "!!str" && interpolation.ContainsInterpolation(item.Value) {
+				l.lintString(ctx, item, item.Value, joinPath(path, valueNode.Content[i].Value))
+				interpolated = true
+			}
+		}
+		for k := range md.Dimensions {
+			scope[k] = nil
+		}
+		for k := range md.Variables {
+			scope[k] = nil
+		}
+		for _, incl := range md.Include {
+			for k := range incl {
+				scope[k] = nil
+			}
+		}
+
+		if !interpolated {
+			jobs := applyExcludes(expandMatrixBase(md), md.Exclude)
+			if jobs, _ = applyIncludes(jobs, md.Include); len(jobs) == 0 {
+				l.report(ctx, keyNode, RuleEmptyMatrix, SeverityWarning, path, fmt.Sprintf("matrix produces no jobs at %s", path))
+			}
+		}
+	default:
+		var v any
+		_ = valueNode.Decode(&v)
+		l.report(ctx, valueNode, RuleInvalidDirective, SeverityError, path, fmt.Sprintf("matrix must be a map, got %T at %s", v, path))
+	}
+
+	if dynamic {
+		l.dynamic++
+		defer func() { l.dynamic-- }()
+	}
+	ctx.Push(scope)
+	l.lintMap(ctx, template)
+	ctx.Pop()
+}
+
+// lintFor checks a for directive, and the template in the scope of its loop variables.
+func (l *linter) lintFor(ctx *Context, node *yaml.Node) {
+	keyNode, valueNode := mappingEntry(node, l.e.config.ForDirective())
+	valueNode = resolveAlias(valueNode)
+	path := joinPath(ctx.Path(), keyNode.Value)
+
+	var forExpr any
+	_ = valueNode.Decode(&forExpr)
+
+	scope := make(map[string]any)
+	switch v := forExpr.(type) {
+	case []any:
+		scope["item"] = nil
+	case string:
+		loopVars, err := parseForExpr(v)
+		if err != nil {
+			l.report(ctx, valueNode, RuleInvalidFor, SeverityError, path, fmt.Sprintf("invalid for expression '%s' at %s: %v", v, path, err))
+			l.dynamic++
+			defer func() { l.dynamic-- }()
+			break
+		}
+		if forSourcePattern.MatchString(loopVars.Source) {
+			l.lintPath(ctx, valueNode, loopVars.Source, path)
+		} else {
+			l.lintExpression(ctx, valueNode, loopVars.Source, path, "error compiling for source '%s' at %s: %v")
+		}
+		for _, name := range loopVars.Variables {
+			if name != "_" {
+				scope[name] = nil
+			}
+		}
+	default:
+		l.report(ctx, valueNode, RuleInvalidFor, SeverityError, path, fmt.Sprintf("for: expected array or string expression, got %T at %s", forExpr, path))
+		l.dynamic++
+		defer func() { l.dynamic-- }()
+	}
+
+	ctx.Push(scope)
+	l.lintMap(ctx, withoutNodeKey(node, keyNode.Value))
+	ctx.Pop()
+}
+
+// lintCondition checks an if directive.
+func (l *linter) lintCondition(ctx *Context, node *yaml.Node) {
+	node = resolveAlias(node)
+	path := joinPath(ctx.Path(), l.e.config.IfDirective())
+
+	var condition any
+	_ = node.Decode(&condition)
+
+	s, ok := condition.(string)
+	if ok && strings.Contains(s, "${") {
+		l.lintString(ctx, node, s, path)
+		return
+	}
+	if err := l.e.compileCondition(condition, path); err != nil {
+		l.report(ctx, node, RuleInvalidExpression, SeverityError, path, err.Error())
+		return
+	}
+	switch s {
+	case "", "true", "false":
+		return
+	case "1", "yes", "0", "no":
+		// Coerced to booleans, unless strict
+		if !l.e.config.Strict {
+			return
+		}
+	}
+	l.lintVariables(ctx, node, s, path)
+}
+
+// lintString checks the interpolations of a string value.
+func (l *linter) lintString(ctx *Context, node *yaml.Node, s string, path string) {
+	for _, segment := range interpolation.Scan(s) {
+		if !segment.Expr {
+			continue
+		}
+		if err := l.e.evaluator.Precompile(segment.Text); err != nil && interpolation.IsPath(segment.Text) {
+			// Variable paths that aren't valid expressions are resolved from the stack
+			l.lintPath(ctx, node, segment.Text, path)
+			continue
+		}
+		l.lintExpression(ctx, node, segment.Text, path, "error compiling expression '%s' at %s: %v")
+	}
+}
+
+// lintExpression checks an expression compiles, and references defined variables.
+// The format formats compile errors, with the expression, path and error.
+func (l *linter) lintExpression(ctx *Context, node *yaml.Node, input, path, format string) {
+	if err := l.e.evaluator.Precompile(input); err != nil {
+		l.report(ctx, node, RuleInvalidExpression, SeverityError, path, fmt.Sprintf(format, input, path, err))
+		return
+	}
+	l.lintVariables(ctx, node, input, path)
+}
+
+// lintVariables reports a variable referenced by an expression that is not defined.
+func (l *linter) lintVariables(ctx *Context, node *yaml.Node, input, path string) {
+	for name := range ctx.Evaluator().Variables(input, ctx.Stack()) {
+		l.used[name] = true
+	}
+	if l.dynamic > 0 {
+		return
+	}
+	if name, ok := ctx.Evaluator().UndefinedVariable(input, ctx.Stack()); ok {
+		l.report(ctx, node, RuleUndefinedVariable, SeverityWarning, path, fmt.Sprintf("undefined variable '%s' at %s", name, path))
+	}
+}
+
+// lintPath reports a variable path with an undefined root variable, e.g. `items` in `items.0`.
+func (l *linter) lintPath(ctx *Context, node *yaml.Node, variablePath, path string) {
+	name, _, _ := strings.Cut(variablePath, ".")
+	name, _, _ = strings.Cut(name, "[")
+	if _, ok := ctx.Stack().Lookup(name); ok {
+		l.used[name] = true
+		return
+	}
+	if l.dynamic == 0 {
+		l.report(ctx, node, RuleUndefinedVariable, SeverityWarning, path, fmt.Sprintf("undefined variable '%s' at %s", name, path))
+	}
+}
+
+// isDirective reports whether key is a directive keyword.
+func (l *linter) isDirective(key string) bool {
+	switch key {
+	case l.e.config.IfDirective(), l.e.config.ForDirective(), l.e.config.IncludeDirective(), l.e.config.MatrixDirective():
+		return true
+	}
+	return false
+}
+
+// contentNode returns the root content node of a document node.
+func contentNode(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		return resolveAlias(node.Content[0])
+	}
+	return resolveAlias(node)
+}
+
+// resolveAlias returns the node an alias node refers to, or node.
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// mappingEntry returns the key and value nodes of key in a mapping node,
+// nil if the mapping has no such key.
+func mappingEntry(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// withoutNodeKey returns a copy of a mapping node without key, like withoutKey.
+func withoutNodeKey(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return node
+	}
+	result := *node
+	result.Content = make([]*yaml.Node, 0, len(node.Content))
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != key {
+			result.Content = append(result.Content, node.Content[i], node.Content[i+1])
+		}
+	}
+	return &result
+}
@@ -0,0 +1,160 @@
+package yamlexpr_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+func TestExpr_Lint(t *testing.T) {
+	fsys := fstest.MapFS{
+		"_labels.yaml": {Data: []byte("team: core\nowner: ${svc}\nregion: ${zone}\n")},
+		"_cycle.yaml":  {Data: []byte("include: _cycle.yaml\n")},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		vars  map[string]any
+		want  []yamlexpr.Diagnostic
+	}{
+		{
+			name: "valid",
+			input: `inputs:
+  env:
+    default: dev
+services:
+  - for: svc in ["api", "worker"]
+    if: svc != "cache"
+    name: ${svc}-${env}
+jobs:
+  - matrix:
+      os: [linux, darwin]
+    name: ${os}
+`,
+		},
+		{
+			name:  "invalid for",
+			input: "items:\n  - for: item of items\n    name: ${item}\n",
+			want: []yamlexpr.Diagnostic{
+				{Rule: yamlexpr.RuleInvalidFor, Severity: yamlexpr.SeverityError, Line: 2, Column: 10, Path: "items[0].for"},
+			},
+		},
+		{
+			name:  "invalid expression",
+			input: "a: 1\nb: ${a +}\n",
+			want: []yamlexpr.Diagnostic{
+				{Rule: yamlexpr.RuleInvalidExpression, Severity: yamlexpr.SeverityError, Line: 2, Column: 4, Path: "b"},
+			},
+		},
+		{
+			name:  "undefined variables",
+			input: "items:\n  - for: v in values\n    if: v > limit\n    name: ${v}-${suffix ?? \"x\"}\n",
+			want: []yamlexpr.Diagnostic{
+				{Rule: yamlexpr.RuleUndefinedVariable, Severity: yamlexpr.SeverityWarning, Line: 2, Column: 10, Path: "items[0].for"},
+				{Rule: yamlexpr.RuleUndefinedVariable, Severity: yamlexpr.SeverityWarning, Line: 3, Column: 9, Path: "items[0].if"},
+			},
+		},
+		{
+			name:  "vars are defined",
+			input: "items:\n  - for: v in values\n    if: v > limit\n    name: ${v}\n",
+			vars:  map[string]any{"values": []any{1}, "limit": 0},
+		},
+		{
+			name:  "misspelled directive",
+			input: "debug:\n  fi: enabled\n  level: 1\n",
+			want: []yamlexpr.Diagnostic{
+				{Rule: yamlexpr.RuleUnknownDirective, Severity: yamlexpr.SeverityWarning, Line: 2, Column: 3, Path: "debug.fi"},
+			},
+		},
+		{
+			name:  "includes",
+			input: "services:\n  - for: svc in [\"api\"]\n    include: _labels.yaml\nmissing:\n  include: [_labels.yaml, _missing.yaml]\ncycle:\n  include: _cycle.yaml\n",
+			want: []yamlexpr.Diagnostic{
+				{Rule: yamlexpr.RuleInvalidInclude, Severity: yamlexpr.SeverityError, File: "_cycle.yaml", Line: 1, Column: 10, Path: "cycle.include"},
+				{Rule: yamlexpr.RuleUndefinedVariable, Severity: yamlexpr.SeverityWarning, File: "_labels.yaml", Line: 2, Column: 8, Path: "missing.owner"},
+				{Rule: yamlexpr.RuleUndefinedVariable, Severity: yamlexpr.SeverityWarning, File: "_labels.yaml", Line: 3, Column: 9, Path: "services[0].region"},
+				{Rule: yamlexpr.RuleMissingInclude, Severity: yamlexpr.SeverityError, Line: 5, Column: 27, Path: "missing.include"},
+			},
+		},
+		{
+			name:  "unused input",
+			input: "inputs:\n  env:\n    default: dev\n  region:\n    default: eu\nname: app-${env}\n",
+			want: []yamlexpr.Diagnostic{
+				{Rule: yamlexpr.RuleUnusedInput, Severity: yamlexpr.SeverityWarning, Line: 4, Column: 3, Path: "inputs.region"},
+			},
+		},
+		{
+			name:  "empty matrix",
+			input: "jobs:\n  - matrix:\n      os: [linux]\n      exclude:\n        - os: linux\n    name: ${os}\n",
+			want: []yamlexpr.Diagnostic{
+				{Rule: yamlexpr.RuleEmptyMatrix, Severity: yamlexpr.SeverityWarning, Line: 2, Column: 5, Path: "jobs[0].matrix"},
+			},
+		},
+		{
+			name:  "interpolated matrix",
+			input: "jobs:\n  - matrix: '${ {\"os\": [\"linux\"]} }'\n    name: ${os}\n",
+		},
+	}
+
+	e := yamlexpr.New(fsys)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := e.LintYAML("app.yaml", []byte(tc.input), tc.vars)
+			require.NoError(t, err)
+			require.Len(t, got, len(tc.want), "%v", got)
+
+			for i, want := range tc.want {
+				if want.File == "" {
+					want.File = "app.yaml"
+				}
+				require.NotEmpty(t, got[i].Message)
+				want.Message = got[i].Message
+				require.Equal(t, want, got[i])
+			}
+		})
+	}
+}
+
+func TestExpr_Lint_Syntax(t *testing.T) {
+	e := yamlexpr.New(nil, yamlexpr.WithSyntax(yamlexpr.Syntax{
+		If:  "when",
+		For: "when",
+	}))
+
+	got, err := e.LintYAML("app.yaml", []byte("name: app\n"), nil)
+	require.NoError(t, err)
+	require.Equal(t, []yamlexpr.Diagnostic{
+		{
+			Rule:     yamlexpr.RuleSyntaxConflict,
+			Severity: yamlexpr.SeverityError,
+			Message:  "syntax keyword 'when' is used for both if and for",
+		},
+	}, got)
+}
+
+func TestExpr_Lint_Strict(t *testing.T) {
+	e := yamlexpr.New(nil, yamlexpr.WithStrict())
+
+	got, err := e.LintYAML("app.yaml", []byte("debug:\n  fro: [1]\n  if: 1\n"), nil)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, yamlexpr.RuleUnknownDirective, got[0].Rule)
+	require.Equal(t, yamlexpr.SeverityError, got[0].Severity)
+	require.Equal(t, yamlexpr.RuleInvalidExpression, got[1].Rule)
+}
+
+func TestDiagnostic_String(t *testing.T) {
+	d := yamlexpr.Diagnostic{
+		Rule:     yamlexpr.RuleUndefinedVariable,
+		Severity: yamlexpr.SeverityWarning,
+		Message:  "undefined variable 'env' at name",
+		File:     "app.yaml",
+		Line:     3,
+		Column:   7,
+	}
+	require.Equal(t, "app.yaml:3:7: warning: undefined variable 'env' at name (undefined-variable)", d.String())
+}
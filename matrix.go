@@ -2,9 +2,13 @@ package yamlexpr
 
 import (
 	"fmt"
+	"maps"
 	"sort"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/titpetric/yamlexpr/model"
+	"github.com/titpetric/yamlexpr/stack"
 )
 
 // MatrixDirective represents the parsed matrix configuration
@@ -189,17 +193,10 @@ func applyIncludes(jobs []map[string]any, includes []map[string]any) ([]map[stri
 //
 // At root level, matrix returns multiple documents.
 // Within a list item, matrix expands to multiple items.
-func (e *Expr) handleMatrixWithContext(ctx *model.Context, matrixValue any, m map[string]any) (any, error) {
-	// Parse matrix map
-	matrixMap, ok := matrixValue.(map[string]any)
-	if !ok {
-		return nil, fmt.Errorf("matrix must be a map, got %T", matrixValue)
-	}
-
-	// Parse matrix directive
-	matrixDir, err := parseMatrixDirective(matrixMap)
+func (e *Expr) handleMatrixWithContext(ctx *model.Context, matrixValue any, m map[string]any, node *yaml.Node) (any, error) {
+	matrixDir, err := e.matrixDirective(ctx, matrixValue)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing matrix: %w", err)
+		return nil, err
 	}
 
 	// Expand base matrix (cartesian product)
@@ -220,6 +217,10 @@ func (e *Expr) handleMatrixWithContext(ctx *model.Context, matrixValue any, m ma
 		allDimensionKeys[k] = true
 	}
 
+	// Dimension keys in the order of the matrix, and the source order of the template
+	dimensionKeys := sourceKeys(sourceValue(node, e.config.MatrixDirective()), matrixDir.Dimensions)
+	templateOrder := sourceKeys(node, m)
+
 	// Collect template keys that might need null values
 	// These are keys that appear in the template as potential interpolations
 	templateKeys := make(map[string]bool)
@@ -246,8 +247,11 @@ func (e *Expr) handleMatrixWithContext(ctx *model.Context, matrixValue any, m ma
 
 		// For template keys that aren't dimensions, initialize to null if not set
 		// This allows template values like "xcode: ${xcode}" to interpolate to null
-		// when the variable isn't in the job
+		// when the variable isn't in the job. Strict mode reports them as undefined.
 		for k := range templateKeys {
+			if e.config.Strict {
+				break
+			}
 			if _, exists := jobVars[k]; !exists {
 				if _, isDim := allDimensionKeys[k]; !isDim {
 					// Non-dimension template key, initialize to null
@@ -269,19 +273,28 @@ func (e *Expr) handleMatrixWithContext(ctx *model.Context, matrixValue any, m ma
 
 		// Create context for this iteration
 		itemCtx := ctx.AppendPath(fmt.Sprintf("[%d]", idx))
+		if e.tracing() {
+			// The scope is cleared when popped, trace a copy
+			itemCtx = e.traceScope(itemCtx, TraceEvent{
+				Kind:  TraceMatrix,
+				Path:  directivePath(ctx, e.config.MatrixDirective()),
+				Index: idx,
+				Vars:  maps.Clone(jobVars),
+			})
+		}
 
 		// Process template with current job in scope
-		expanded, err := e.processMapWithContext(itemCtx, template)
+		expanded, err := e.processMapWithContext(itemCtx, template, node)
 		if err != nil {
 			ctx.Pop()
 			return nil, err
 		}
 
 		// After processing, merge in keys that should always be present
-		if expandedMap, ok := expanded.(map[string]any); ok {
+		if expandedMap, ok := expanded.(*mapping); ok {
 			// Add dimension keys with their values
-			for dimKey := range matrixDir.Dimensions {
-				expandedMap[dimKey] = jobVars[dimKey]
+			for _, dimKey := range dimensionKeys {
+				expandedMap.set(dimKey, jobVars[dimKey])
 			}
 
 			// Add back non-dimension template keys that resulted in null
@@ -290,13 +303,16 @@ func (e *Expr) handleMatrixWithContext(ctx *model.Context, matrixValue any, m ma
 				if _, isDim := allDimensionKeys[k]; !isDim {
 					// This is a non-dimension template key
 					// If it's not in expanded but is in jobVars, add it
-					if _, exists := expandedMap[k]; !exists {
+					if _, exists := expandedMap.values[k]; !exists {
 						if val, hasVal := jobVars[k]; hasVal {
-							expandedMap[k] = val
+							expandedMap.set(k, val)
 						}
 					}
 				}
 			}
+
+			// Order the dimension keys at the matrix directive
+			expandedMap.keys = placeKeys(expandedMap.keys, templateOrder, e.config.MatrixDirective(), dimensionKeys)
 		}
 
 		if expanded != nil {
@@ -309,3 +325,60 @@ func (e *Expr) handleMatrixWithContext(ctx *model.Context, matrixValue any, m ma
 
 	return result, nil
 }
+
+// matrixDirective returns the parsed matrix directive of matrixValue.
+// Matrix values without interpolations are parsed by Compile.
+func (e *Expr) matrixDirective(ctx *model.Context, matrixValue any) (*MatrixDirective, error) {
+	if compiled, ok := matrixValue.(*compiledMatrix); ok {
+		return compiled.directive, nil
+	}
+
+	// Evaluate interpolated matrix values
+	matrixMap, err := e.interpolateMatrix(ctx, matrixValue)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse matrix directive
+	matrixDir, err := parseMatrixDirective(matrixMap)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing matrix: %w", err)
+	}
+	return matrixDir, nil
+}
+
+// interpolateMatrix evaluates interpolations in the matrix value.
+// The matrix itself may be an interpolation (e.g. `matrix: ${jobs}`),
+// and dimension values may be interpolations returning arrays (e.g. `os: ${platforms}`).
+func (e *Expr) interpolateMatrix(ctx *model.Context, matrixValue any) (map[string]any, error) {
+	path := ctx.Path() + "." + e.config.MatrixDirective()
+
+	if str, ok := matrixValue.(string); ok {
+		val, err := ctx.Evaluator().InterpolateValueWithContext(str, ctx.Stack(), path)
+		if err != nil {
+			return nil, err
+		}
+		matrixValue = val
+	}
+
+	matrixMap, ok := matrixValue.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("matrix must be a map, got %T", matrixValue)
+	}
+
+	result := make(map[string]any, len(matrixMap))
+	for k, v := range matrixMap {
+		if str, ok := v.(string); ok {
+			val, err := ctx.Evaluator().InterpolateValueWithContext(str, ctx.Stack(), path+"."+k)
+			if err != nil {
+				return nil, err
+			}
+			v = val
+		}
+		if stack.IsSlice(v) {
+			v = stack.SliceToAny(v)
+		}
+		result[k] = v
+	}
+	return result, nil
+}
@@ -557,3 +557,31 @@ func TestParseMatrixDirective_EdgeCases(t *testing.T) {
 		require.Len(t, directive.Exclude, 2)
 	})
 }
+
+// BenchmarkExpr_ParseMatrix benchmarks a root level matrix expanding to 1000 jobs.
+func BenchmarkExpr_ParseMatrix(b *testing.B) {
+	values := func(prefix string) []any {
+		result := make([]any, 10)
+		for i := range result {
+			result[i] = prefix + string(rune('a'+i))
+		}
+		return result
+	}
+
+	e := New(nil)
+	b.ReportAllocs()
+	for b.Loop() {
+		docs, err := e.Parse(map[string]any{
+			"matrix": map[string]any{
+				"os":      values("os-"),
+				"arch":    values("arch-"),
+				"version": values("v"),
+			},
+			"name":    "${os}-${arch}-${version}",
+			"image":   "registry/${os | upper}:${version}",
+			"release": "${version != 'va' && arch != 'arch-a'}",
+		})
+		require.NoError(b, err)
+		require.Len(b, docs, 1000)
+	}
+}
@@ -2,6 +2,7 @@ package yamlexpr
 
 import (
 	"github.com/titpetric/yamlexpr/frontmatter"
+	"github.com/titpetric/yamlexpr/interpolation"
 	"github.com/titpetric/yamlexpr/model"
 )
 
@@ -19,10 +20,46 @@ type (
 	DirectiveHandler = model.DirectiveHandler
 	// Syntax aliases model.SyntaxHandler.
 	Syntax = model.Syntax
+	// EnvOptions aliases model.EnvOptions.
+	EnvOptions = model.EnvOptions
+	// SecretProvider aliases model.SecretProvider.
+	SecretProvider = model.SecretProvider
+	// SecretProviderFunc aliases model.SecretProviderFunc.
+	SecretProviderFunc = model.SecretProviderFunc
+	// FileSecrets aliases model.FileSecrets.
+	FileSecrets = model.FileSecrets
+	// EnvSecrets aliases model.EnvSecrets.
+	EnvSecrets = model.EnvSecrets
+	// Tracer aliases model.Tracer.
+	Tracer = model.Tracer
+	// TraceEvent aliases model.TraceEvent.
+	TraceEvent = model.TraceEvent
+	// TraceKind aliases model.TraceKind.
+	TraceKind = model.TraceKind
+	// Trace aliases model.Trace.
+	Trace = model.Trace
+	// Undefined aliases interpolation.Undefined.
+	Undefined = interpolation.Undefined
+	// UndefinedFunc aliases interpolation.UndefinedFunc.
+	UndefinedFunc = interpolation.UndefinedFunc
 	// DocumentContent aliases frontmatter.DocumentContent.
 	DocumentContent = frontmatter.DocumentContent
 )
 
+// Model constant aliases.
+const (
+	// TraceInclude aliases model.TraceInclude.
+	TraceInclude = model.TraceInclude
+	// TraceCondition aliases model.TraceCondition.
+	TraceCondition = model.TraceCondition
+	// TraceFor aliases model.TraceFor.
+	TraceFor = model.TraceFor
+	// TraceMatrix aliases model.TraceMatrix.
+	TraceMatrix = model.TraceMatrix
+	// TraceValue aliases model.TraceValue.
+	TraceValue = model.TraceValue
+)
+
 // Model function/value aliases.
 var (
 	// DefaultConfig aliases model.DefaultConfig.
@@ -33,6 +70,30 @@ var (
 	WithFS = model.WithFS
 	// WithSyntax aliases model.WithFS.
 	WithSyntax = model.WithSyntax
+	// WithEnv aliases model.WithEnv.
+	WithEnv = model.WithEnv
+	// WithFunction aliases model.WithFunction.
+	WithFunction = model.WithFunction
+	// WithExprOptions aliases model.WithExprOptions.
+	WithExprOptions = model.WithExprOptions
+	// WithStrict aliases model.WithStrict.
+	WithStrict = model.WithStrict
+	// WithUndefined aliases model.WithUndefined.
+	WithUndefined = model.WithUndefined
+	// WithSecrets aliases model.WithSecrets.
+	WithSecrets = model.WithSecrets
+	// WithSchema aliases model.WithSchema.
+	WithSchema = model.WithSchema
+	// WithTracer aliases model.WithTracer.
+	WithTracer = model.WithTracer
+	// UndefinedError aliases interpolation.UndefinedError.
+	UndefinedError = interpolation.UndefinedError
+	// UndefinedEmpty aliases interpolation.UndefinedEmpty.
+	UndefinedEmpty = interpolation.UndefinedEmpty
+	// UndefinedKeep aliases interpolation.UndefinedKeep.
+	UndefinedKeep = interpolation.UndefinedKeep
+	// UndefinedNull aliases interpolation.UndefinedNull.
+	UndefinedNull = interpolation.UndefinedNull
 	// ParseDocument aliases frontmatter.ParseDocument.
 	ParseDocument = frontmatter.ParseDocument
 )
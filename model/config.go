@@ -2,6 +2,10 @@ package model
 
 import (
 	"io/fs"
+
+	"github.com/expr-lang/expr"
+
+	"github.com/titpetric/yamlexpr/interpolation"
 )
 
 // Syntax defines the directive keywords used in YAML documents.
@@ -15,6 +19,8 @@ type Syntax struct {
 	Include string `json:"include" yaml:"include"`
 	// Matrix is the directive keyword for matrix iteration (default: "matrix").
 	Matrix string `json:"matrix" yaml:"matrix"`
+	// Inputs is the root directive keyword for input declarations (default: "inputs").
+	Inputs string `json:"inputs" yaml:"inputs"`
 }
 
 // DefaultSyntax is the default syntax configuration with standard directive names.
@@ -23,6 +29,7 @@ var DefaultSyntax = Syntax{
 	For:     "for",
 	Include: "include",
 	Matrix:  "matrix",
+	Inputs:  "inputs",
 }
 
 // Config holds configuration options for the Expr evaluator.
@@ -35,6 +42,20 @@ type Config struct {
 	HandlerOrder []string
 	// filesystem is the FS used for loading resources (can be nil)
 	FS fs.FS
+	// Env configures environment variable access from expressions (nil disables it)
+	Env *EnvOptions
+	// ExprOptions are additional expression options (functions, operators) used at every compile site
+	ExprOptions []expr.Option
+	// Strict disables implicit coercions and reports misspelled directives
+	Strict bool
+	// Undefined is the policy for undefined variables (nil reports errors)
+	Undefined interpolation.UndefinedFunc
+	// Secrets resolves secrets and tracks their values for redaction (nil disables the secret function)
+	Secrets *Secrets
+	// Schema is the path of a JSON Schema validating processed documents (empty disables validation)
+	Schema string
+	// Tracer receives processing events (nil disables tracing)
+	Tracer Tracer
 }
 
 // DefaultConfig returns the default configuration with standard directive names.
@@ -81,6 +102,9 @@ func WithSyntax(syntax Syntax) ConfigOption {
 		if syntax.Matrix != "" {
 			cfg.Syntax.Matrix = syntax.Matrix
 		}
+		if syntax.Inputs != "" {
+			cfg.Syntax.Inputs = syntax.Inputs
+		}
 	}
 }
 
@@ -104,6 +128,11 @@ func (c *Config) MatrixDirective() string {
 	return c.Syntax.Matrix
 }
 
+// InputsDirective returns the current inputs directive keyword.
+func (c *Config) InputsDirective() string {
+	return c.Syntax.Inputs
+}
+
 // WithDirectiveHandler registers a custom handler for a directive name.
 // The handler will be called for any block containing the specified directive.
 //
@@ -140,3 +169,89 @@ func WithFS(filesystem fs.FS) ConfigOption {
 		cfg.FS = filesystem
 	}
 }
+
+// WithStrict enables strict processing:
+//   - conditions must be booleans or expressions evaluating to a boolean,
+//     strings like "yes", "1" or "" are not coerced,
+//   - interpolated conditions are not quoted to form string comparisons,
+//   - matrix templates don't default undefined variables to null,
+//   - keys resembling a directive (e.g. `fro:`) are reported as errors.
+//
+// Example:
+//
+//	e := yamlexpr.New(fs, yamlexpr.WithStrict())
+func WithStrict() ConfigOption {
+	return func(cfg *Config) {
+		cfg.Strict = true
+	}
+}
+
+// WithUndefined sets the policy for undefined variables in interpolations,
+// conditions and for sources. The interpolation package provides the
+// UndefinedError, UndefinedEmpty, UndefinedKeep and UndefinedNull policies,
+// or a custom function can decide the value for each undefined variable.
+// In conditions and for sources, placeholder text kept by a policy is null.
+// Independent of the policy, `${ name ?? "default" }` provides a default value.
+//
+// Example:
+//
+//	e := yamlexpr.New(fs, yamlexpr.WithUndefined(yamlexpr.UndefinedKeep))
+func WithUndefined(fn interpolation.UndefinedFunc) ConfigOption {
+	return func(cfg *Config) {
+		cfg.Undefined = fn
+	}
+}
+
+// WithSchema validates processed documents against a JSON or YAML encoded
+// JSON Schema, loaded from the filesystem provided to New(). A document can
+// select a different schema with a root `$schema` key holding a path.
+//
+// Example:
+//
+//	e := yamlexpr.New(fs, yamlexpr.WithSchema("schema/deployment.json"))
+func WithSchema(filename string) ConfigOption {
+	return func(cfg *Config) {
+		cfg.Schema = filename
+	}
+}
+
+// CompileOptions returns the expression options applied when compiling
+// interpolations and conditions.
+func (c *Config) CompileOptions() []expr.Option {
+	var opts []expr.Option
+	if c.Env != nil {
+		opts = append(opts, c.Env.exprOptions()...)
+	}
+	if c.Secrets != nil {
+		opts = append(opts, c.Secrets.exprOptions()...)
+	}
+	return append(opts, c.ExprOptions...)
+}
+
+// WithFunction registers a custom function available in interpolations,
+// conditions, for sources and matrix values.
+// Optional types declare function signatures for compile-time type checking,
+// see expr.Function for details.
+//
+// Example:
+//
+//	e := yamlexpr.New(fs, yamlexpr.WithFunction("slug", func(params ...any) (any, error) {
+//		return strings.ToLower(strings.ReplaceAll(params[0].(string), " ", "-")), nil
+//	}, new(func(string) string)))
+func WithFunction(name string, fn func(params ...any) (any, error), types ...any) ConfigOption {
+	return WithExprOptions(expr.Function(name, fn, types...))
+}
+
+// WithExprOptions adds expr-lang options applied when compiling expressions.
+// Options are applied in registration order, after the evaluation environment.
+//
+// Example:
+//
+//	e := yamlexpr.New(fs, yamlexpr.WithExprOptions(
+//		expr.Timezone("Europe/Ljubljana"),
+//	))
+func WithExprOptions(opts ...expr.Option) ConfigOption {
+	return func(cfg *Config) {
+		cfg.ExprOptions = append(cfg.ExprOptions, opts...)
+	}
+}
@@ -3,6 +3,9 @@ package model
 import (
 	"strings"
 
+	"github.com/expr-lang/expr"
+
+	"github.com/titpetric/yamlexpr/interpolation"
 	"github.com/titpetric/yamlexpr/stack"
 )
 
@@ -18,6 +21,12 @@ type Context struct {
 
 	// includeChain tracks the chain of included files for error context
 	includeChain []string
+
+	// evaluator compiles and evaluates expressions, caching compiled programs
+	evaluator *interpolation.Evaluator
+
+	// trace holds the include, condition and iteration events scoping the context
+	trace *Trace
 }
 
 // NewContext returns a Context initialized for the given options.
@@ -30,6 +39,7 @@ func NewContext(options *ContextOptions) *Context {
 		stack:        options.Stack,
 		path:         options.Path,
 		includeChain: options.IncludeChain,
+		evaluator:    options.Evaluator,
 	}
 
 	if ctx.stack == nil {
@@ -38,6 +48,9 @@ func NewContext(options *ContextOptions) *Context {
 	if ctx.includeChain == nil {
 		ctx.includeChain = []string{}
 	}
+	if ctx.evaluator == nil {
+		ctx.evaluator = interpolation.NewEvaluator(options.ExprOptions...)
+	}
 
 	return ctx
 }
@@ -52,6 +65,16 @@ func (ctx *Context) Path() string {
 	return ctx.path
 }
 
+// ExprOptions returns the options applied when compiling expressions.
+func (ctx *Context) ExprOptions() []expr.Option {
+	return ctx.evaluator.Options()
+}
+
+// Evaluator returns the expression evaluator.
+func (ctx *Context) Evaluator() *interpolation.Evaluator {
+	return ctx.evaluator
+}
+
 // WithPath returns a new context with the path updated.
 // Useful for tracking location while descending into nested structures.
 func (ctx *Context) WithPath(newPath string) *Context {
@@ -59,6 +82,8 @@ func (ctx *Context) WithPath(newPath string) *Context {
 		stack:        ctx.stack,
 		path:         newPath,
 		includeChain: ctx.includeChain,
+		evaluator:    ctx.evaluator,
+		trace:        ctx.trace,
 	}
 }
 
@@ -100,6 +125,29 @@ func (ctx *Context) WithInclude(filename string) *Context {
 		stack:        ctx.stack,
 		path:         ctx.path,
 		includeChain: newChain,
+		evaluator:    ctx.evaluator,
+		trace:        ctx.trace,
+	}
+}
+
+// IncludeChain returns the chain of included files.
+func (ctx *Context) IncludeChain() []string {
+	return ctx.includeChain
+}
+
+// Trace returns the events scoping the context, nil if there are none.
+func (ctx *Context) Trace() *Trace {
+	return ctx.trace
+}
+
+// WithTrace returns a new context scoped by an event, e.g. a loop iteration.
+func (ctx *Context) WithTrace(event TraceEvent) *Context {
+	return &Context{
+		stack:        ctx.stack,
+		path:         ctx.path,
+		includeChain: ctx.includeChain,
+		evaluator:    ctx.evaluator,
+		trace:        &Trace{Parent: ctx.trace, Event: event},
 	}
 }
 
@@ -1,6 +1,11 @@
 package model
 
-import "github.com/titpetric/yamlexpr/stack"
+import (
+	"github.com/expr-lang/expr"
+
+	"github.com/titpetric/yamlexpr/interpolation"
+	"github.com/titpetric/yamlexpr/stack"
+)
 
 // ContextOptions holds configurable options for a new Context.
 type ContextOptions struct {
@@ -12,4 +17,12 @@ type ContextOptions struct {
 
 	// IncludeChain is the initial chain of included files.
 	IncludeChain []string
+
+	// ExprOptions are applied when compiling expressions.
+	// Ignored if Evaluator is set.
+	ExprOptions []expr.Option
+
+	// Evaluator compiles and evaluates expressions (defaults to a new
+	// evaluator with ExprOptions). Share it to reuse compiled programs.
+	Evaluator *interpolation.Evaluator
 }
@@ -0,0 +1,140 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+)
+
+// EnvFunction is the name of the expression function (and identifier) exposing environment variables.
+const EnvFunction = "env"
+
+// EnvOptions configures access to process environment variables from expressions.
+// Access is denied for every variable that doesn't match Prefix or Allow,
+// unless both are empty, in which case every variable is accessible.
+type EnvOptions struct {
+	// Prefix allows access to variables whose name starts with the prefix (e.g. "APP_").
+	Prefix string
+	// Allow lists variable names that are accessible regardless of Prefix.
+	Allow []string
+	// Lookup resolves an environment variable (default: os.LookupEnv).
+	// Tests can replace it to avoid depending on the real environment.
+	Lookup func(name string) (string, bool)
+}
+
+// WithEnv exposes environment variables to interpolation and conditions.
+// Passing nil exposes all variables of the process environment.
+//
+// Variables are available with `env.NAME`, or with `env.NAME ?? "default"`
+// and `env("NAME", "default")` when a default value should be used for
+// unset variables.
+//
+// Example:
+//
+//	e := yamlexpr.New(fs, yamlexpr.WithEnv(&yamlexpr.EnvOptions{
+//		Prefix: "APP_",
+//		Allow:  []string{"HOME"},
+//	}))
+func WithEnv(options *EnvOptions) ConfigOption {
+	return func(cfg *Config) {
+		if options == nil {
+			options = &EnvOptions{}
+		}
+		cfg.Env = options
+	}
+}
+
+// Allowed returns true if the variable name is accessible.
+func (o *EnvOptions) Allowed(name string) bool {
+	if o.Prefix == "" && len(o.Allow) == 0 {
+		return true
+	}
+	if o.Prefix != "" && strings.HasPrefix(name, o.Prefix) {
+		return true
+	}
+	return slices.Contains(o.Allow, name)
+}
+
+// Get returns the value of an accessible environment variable.
+func (o *EnvOptions) Get(name string) (string, bool) {
+	if !o.Allowed(name) {
+		return "", false
+	}
+	lookup := o.Lookup
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	return lookup(name)
+}
+
+// exprOptions returns the expression options providing the env function.
+func (o *EnvOptions) exprOptions() []expr.Option {
+	return []expr.Option{
+		expr.Function(EnvFunction, o.call,
+			new(func(string) string),
+			new(func(string, string) string),
+			new(func(string, any) any),
+		),
+		expr.Patch(envPatcher{}),
+	}
+}
+
+// call implements env(name) and env(name, default).
+func (o *EnvOptions) call(params ...any) (any, error) {
+	name := params[0].(string)
+	if !o.Allowed(name) {
+		return nil, fmt.Errorf("environment variable '%s' is not allowed", name)
+	}
+	if val, ok := o.Get(name); ok {
+		return val, nil
+	}
+	if len(params) > 1 {
+		return params[1], nil
+	}
+	return nil, fmt.Errorf("environment variable '%s' is not set", name)
+}
+
+// envPatcher rewrites member access like env.HOME or env["HOME"] into env("HOME").
+// Rewriting only occurs when env resolves to the function, so a variable named
+// env in the document takes precedence. On the left side of the ?? operator,
+// env("HOME") is rewritten into env("HOME", nil), so unset variables are null.
+type envPatcher struct{}
+
+// Visit implements ast.Visitor.
+func (envPatcher) Visit(node *ast.Node) {
+	if binary, ok := (*node).(*ast.BinaryNode); ok && binary.Operator == "??" {
+		left := binary.Left
+		if chain, ok := left.(*ast.ChainNode); ok {
+			left = chain.Node
+		}
+		call, ok := left.(*ast.CallNode)
+		if !ok || len(call.Arguments) != 1 {
+			return
+		}
+		if callee, ok := call.Callee.(*ast.IdentifierNode); ok && callee.Value == EnvFunction {
+			call.Arguments = append(call.Arguments, &ast.NilNode{})
+		}
+		return
+	}
+
+	member, ok := (*node).(*ast.MemberNode)
+	if !ok {
+		return
+	}
+	ident, ok := member.Node.(*ast.IdentifierNode)
+	if !ok || ident.Value != EnvFunction || ident.Nature().Func == nil {
+		return
+	}
+	property, ok := member.Property.(*ast.StringNode)
+	if !ok {
+		return
+	}
+	ast.Patch(node, &ast.CallNode{
+		Callee:    &ast.IdentifierNode{Value: EnvFunction},
+		Arguments: []ast.Node{&ast.StringNode{Value: property.Value}},
+	})
+}
@@ -0,0 +1,195 @@
+package model
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/expr-lang/expr"
+)
+
+// SecretFunction is the name of the expression function resolving secrets.
+const SecretFunction = "secret"
+
+// SecretMask replaces secret values in error messages and traces.
+const SecretMask = "***"
+
+// SecretProvider resolves secrets by name, e.g. "db/password".
+type SecretProvider interface {
+	Secret(name string) (string, error)
+}
+
+// SecretProviderFunc is a function implementing SecretProvider.
+type SecretProviderFunc func(name string) (string, error)
+
+// Secret implements SecretProvider.
+func (f SecretProviderFunc) Secret(name string) (string, error) {
+	return f(name)
+}
+
+// FileSecrets resolves secrets from files, e.g. `secret("db/password")`
+// reads the file db/password. A single trailing newline is removed.
+// It suits mounted secrets like /run/secrets.
+type FileSecrets struct {
+	// FS is the filesystem holding the secret files.
+	FS fs.FS
+}
+
+// Secret implements SecretProvider.
+func (p *FileSecrets) Secret(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", fmt.Errorf("invalid secret name '%s'", name)
+	}
+	data, err := fs.ReadFile(p.FS, name)
+	if err != nil {
+		return "", fmt.Errorf("secret '%s' not found: %w", name, err)
+	}
+	val := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(val, "\r"), nil
+}
+
+// EnvSecrets resolves secrets from environment variables. The variable name
+// is the prefix followed by the upper cased secret name, with characters
+// other than letters and digits replaced by underscores, e.g.
+// `secret("db/password")` reads SECRET_DB_PASSWORD with the prefix "SECRET_".
+type EnvSecrets struct {
+	// Prefix is prepended to variable names.
+	Prefix string
+	// Lookup resolves an environment variable (default: os.LookupEnv).
+	Lookup func(name string) (string, bool)
+}
+
+// Secret implements SecretProvider.
+func (p *EnvSecrets) Secret(name string) (string, error) {
+	variable := p.Prefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+
+	lookup := p.Lookup
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	if val, ok := lookup(variable); ok {
+		return val, nil
+	}
+	return "", fmt.Errorf("secret '%s' not found: environment variable %s is not set", name, variable)
+}
+
+// Secrets resolves secrets and tracks their values for redaction.
+// Resolved secrets are cached. Secrets is safe for concurrent use.
+type Secrets struct {
+	provider SecretProvider
+
+	mu       sync.RWMutex
+	values   map[string]string
+	replacer *strings.Replacer
+}
+
+// NewSecrets returns Secrets resolving secrets with provider.
+func NewSecrets(provider SecretProvider) *Secrets {
+	return &Secrets{
+		provider: provider,
+		values:   make(map[string]string),
+	}
+}
+
+// WithSecrets enables the secret function, resolving secrets with provider.
+// Secret values are written to the output documents in full, and replaced
+// with SecretMask in error messages.
+//
+// Example:
+//
+//	e := yamlexpr.New(fs, yamlexpr.WithSecrets(&yamlexpr.FileSecrets{
+//		FS: os.DirFS("/run/secrets"),
+//	}))
+//
+// A document can then use `password: ${secret("db/password")}`.
+func WithSecrets(provider SecretProvider) ConfigOption {
+	return func(cfg *Config) {
+		cfg.Secrets = NewSecrets(provider)
+	}
+}
+
+// Get resolves a secret by name.
+func (s *Secrets) Get(name string) (string, error) {
+	s.mu.RLock()
+	val, ok := s.values[name]
+	s.mu.RUnlock()
+	if ok {
+		return val, nil
+	}
+
+	val, err := s.provider.Secret(name)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	s.values[name] = val
+	s.replacer = nil
+	s.mu.Unlock()
+
+	return val, nil
+}
+
+// Redact replaces resolved secret values in text with SecretMask.
+// Longer values are replaced first, so overlapping secrets are fully masked.
+func (s *Secrets) Redact(text string) string {
+	if s == nil {
+		return text
+	}
+
+	s.mu.RLock()
+	replacer := s.replacer
+	s.mu.RUnlock()
+
+	if replacer == nil {
+		s.mu.Lock()
+		if s.replacer == nil {
+			s.replacer = s.newReplacer()
+		}
+		replacer = s.replacer
+		s.mu.Unlock()
+	}
+	return replacer.Replace(text)
+}
+
+// newReplacer returns a replacer masking secret values. The caller must hold the lock.
+func (s *Secrets) newReplacer() *strings.Replacer {
+	values := make([]string, 0, len(s.values))
+	for _, val := range s.values {
+		if val != "" {
+			values = append(values, val)
+		}
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+
+	pairs := make([]string, 0, len(values)*2)
+	for _, val := range values {
+		pairs = append(pairs, val, SecretMask)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// exprOptions returns the expression options providing the secret function.
+func (s *Secrets) exprOptions() []expr.Option {
+	return []expr.Option{
+		expr.Function(SecretFunction, s.call, new(func(string) string)),
+	}
+}
+
+// call implements secret(name).
+func (s *Secrets) call(params ...any) (any, error) {
+	return s.Get(params[0].(string))
+}
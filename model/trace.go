@@ -0,0 +1,76 @@
+package model
+
+// TraceKind identifies the processing step of a TraceEvent.
+type TraceKind string
+
+// Trace event kinds.
+const (
+	// TraceInclude is reported when a file is included.
+	TraceInclude TraceKind = "include"
+	// TraceCondition is reported when an if condition is evaluated.
+	TraceCondition TraceKind = "if"
+	// TraceFor is reported for each iteration of a for loop.
+	TraceFor TraceKind = "for"
+	// TraceMatrix is reported for each job of a matrix.
+	TraceMatrix TraceKind = "matrix"
+	// TraceValue is reported when a value is interpolated.
+	TraceValue TraceKind = "value"
+)
+
+// TraceEvent describes a processing step.
+type TraceEvent struct {
+	// Kind is the processing step.
+	Kind TraceKind `json:"kind" yaml:"kind"`
+	// Path is the document path of the directive or value, as used in errors.
+	Path string `json:"path" yaml:"path"`
+	// File is the included file for include events, empty otherwise.
+	File string `json:"file,omitempty" yaml:"file,omitempty"`
+	// Expression is the condition, the for expression or the interpolated string.
+	Expression string `json:"expression,omitempty" yaml:"expression,omitempty"`
+	// Result is the condition result, or the interpolated value.
+	Result any `json:"result,omitempty" yaml:"result,omitempty"`
+	// Index is the iteration index of for and matrix events.
+	Index int `json:"index" yaml:"index"`
+	// Vars are the loop variables or matrix job of iterations, and the
+	// variables referenced by conditions and interpolations.
+	Vars map[string]any `json:"vars,omitempty" yaml:"vars,omitempty"`
+}
+
+// Tracer receives trace events while documents are processed.
+// The context is the processing context of the event.
+type Tracer func(ctx *Context, event TraceEvent)
+
+// Trace is a chain of include, condition and iteration events that
+// scope a context, with the innermost event last.
+type Trace struct {
+	// Parent is the enclosing trace, nil for the outermost event.
+	Parent *Trace
+	// Event is the scoping event.
+	Event TraceEvent
+}
+
+// Events returns the events of the trace, outermost first.
+func (t *Trace) Events() []TraceEvent {
+	var events []TraceEvent
+	for ; t != nil; t = t.Parent {
+		events = append(events, t.Event)
+	}
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events
+}
+
+// WithTracer sets a tracer receiving processing events.
+// Secret values resolved with WithSecrets are redacted in the events.
+//
+// Example:
+//
+//	e := yamlexpr.New(fs, yamlexpr.WithTracer(func(ctx *yamlexpr.Context, ev yamlexpr.TraceEvent) {
+//		log.Printf("%s %s: %s = %v", ev.Kind, ev.Path, ev.Expression, ev.Result)
+//	}))
+func WithTracer(tracer Tracer) ConfigOption {
+	return func(c *Config) {
+		c.Tracer = tracer
+	}
+}
@@ -0,0 +1,402 @@
+package yamlexpr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a YAML document rendered by an Expr: a map keeping the key
+// order of the YAML file it was rendered from, including the files it
+// includes. Keys merged by an include are ordered at the include directive,
+// and keys of maps not originating from a file are sorted.
+//
+// Documents encode with keys in order with yaml.Marshal. A copy of a
+// Document shares its values and key order.
+type Document struct {
+	values map[string]any
+	order  *keyOrder
+}
+
+// NewDocument returns a Document with the values of m. Keys are encoded sorted.
+func NewDocument(m map[string]any) Document {
+	if m == nil {
+		m = make(map[string]any)
+	}
+	return Document{values: m}
+}
+
+// Map returns the values of the document. Nested maps are map[string]any.
+func (d Document) Map() map[string]any {
+	return d.values
+}
+
+// Keys returns the keys of the document in order.
+func (d Document) Keys() []string {
+	return d.order.sort(d.values)
+}
+
+// MarshalYAML encodes the document with keys in order.
+func (d Document) MarshalYAML() (any, error) {
+	return d.order.node(d.values)
+}
+
+// MarshalJSON encodes the document as a JSON object.
+func (d Document) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.values)
+}
+
+// keyOrder is the key order of a rendered value: the keys of a map, and the
+// key order of its values or of the items of a slice. Keys not listed are sorted.
+type keyOrder struct {
+	keys   []string
+	values map[string]*keyOrder
+	items  []*keyOrder
+}
+
+// sort returns the keys of m in order. A nil keyOrder sorts the keys.
+func (o *keyOrder) sort(m map[string]any) []string {
+	if o == nil {
+		return orderKeys(nil, m)
+	}
+	return orderKeys(o.keys, m)
+}
+
+// orderKeys returns the keys of m in the order of listed, followed by the
+// keys not listed, sorted.
+func orderKeys[V any](listed []string, m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	seen := make(map[string]bool, len(m))
+	for _, k := range listed {
+		if _, ok := m[k]; ok && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+
+	var rest []string
+	for k := range m {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// value returns the key order of the value of a map key.
+func (o *keyOrder) value(key string) *keyOrder {
+	if o == nil {
+		return nil
+	}
+	return o.values[key]
+}
+
+// item returns the key order of a slice item.
+func (o *keyOrder) item(i int) *keyOrder {
+	if o == nil || i >= len(o.items) {
+		return nil
+	}
+	return o.items[i]
+}
+
+// node converts a value to a YAML node with map keys in order.
+func (o *keyOrder) node(value any) (*yaml.Node, error) {
+	switch v := value.(type) {
+	case Document:
+		return v.order.node(v.values)
+	case map[string]any:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, k := range o.sort(v) {
+			valueNode, err := o.value(k).node(v[k])
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, valueNode)
+		}
+		return node, nil
+	case []any:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for i, item := range v {
+			itemNode, err := o.item(i).node(item)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, itemNode)
+		}
+		return node, nil
+	default:
+		node := &yaml.Node{}
+		if err := node.Encode(v); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+}
+
+// lookup returns the key order of value, if value is a map or a non-empty
+// slice in at.
+func (o *keyOrder) lookup(value, at any) (*keyOrder, bool) {
+	switch v := at.(type) {
+	case map[string]any:
+		if m, ok := value.(map[string]any); ok && pointer(m) == pointer(v) {
+			return o, true
+		}
+		for k, item := range v {
+			if order, ok := o.value(k).lookup(value, item); ok {
+				return order, true
+			}
+		}
+	case []any:
+		if s, ok := value.([]any); ok && len(s) > 0 && len(s) == len(v) && pointer(s) == pointer(v) {
+			return o, true
+		}
+		for i, item := range v {
+			if order, ok := o.item(i).lookup(value, item); ok {
+				return order, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// withOrder returns value with a key order: maps as Documents, and slices
+// with their items ordered.
+func (o *keyOrder) withOrder(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return Document{values: v, order: o}
+	case []any:
+		if o == nil {
+			return v
+		}
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = o.item(i).withOrder(item)
+		}
+		return items
+	}
+	return value
+}
+
+// mapping is a map being rendered, with its keys in source order.
+type mapping struct {
+	keys   []string
+	values map[string]any
+}
+
+// newMapping returns an empty mapping.
+func newMapping() *mapping {
+	return &mapping{values: make(map[string]any)}
+}
+
+// set sets the value of a key, appending new keys.
+func (m *mapping) set(key string, value any) {
+	if !slices.Contains(m.keys, key) {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// asMapping returns v as a mapping, wrapping maps with sorted keys.
+func asMapping(v any) (*mapping, bool) {
+	switch m := v.(type) {
+	case *mapping:
+		return m, true
+	case map[string]any:
+		return &mapping{keys: (*keyOrder)(nil).sort(m), values: m}, true
+	}
+	return nil, false
+}
+
+// mapValues returns the values of a map or mapping.
+func mapValues(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case *mapping:
+		return m.values, true
+	case map[string]any:
+		return m, true
+	}
+	return nil, false
+}
+
+// detach replaces the mappings in a rendered value with their maps, and
+// returns the key order of the value. Maps keep their identity, and maps
+// without mappings are left unchanged, as they may be shared with variables.
+func detach(value any) (any, *keyOrder) {
+	switch v := value.(type) {
+	case *mapping:
+		order := detachValues(v.values)
+		if order == nil {
+			order = &keyOrder{}
+		}
+		order.keys = v.keys
+		return v.values, order
+	case map[string]any:
+		return v, detachValues(v)
+	case []any:
+		var order *keyOrder
+		for i, item := range v {
+			detached, itemOrder := detach(item)
+			if _, ok := item.(*mapping); ok {
+				v[i] = detached
+			}
+			if itemOrder != nil {
+				if order == nil {
+					order = &keyOrder{items: make([]*keyOrder, len(v))}
+				}
+				order.items[i] = itemOrder
+			}
+		}
+		return v, order
+	}
+	return value, nil
+}
+
+// detachValues detaches the values of a map, returning their key order.
+func detachValues(m map[string]any) *keyOrder {
+	var order *keyOrder
+	for k, item := range m {
+		detached, itemOrder := detach(item)
+		if _, ok := item.(*mapping); ok {
+			m[k] = detached
+		}
+		if itemOrder != nil {
+			if order == nil {
+				order = &keyOrder{values: make(map[string]*keyOrder)}
+			}
+			order.values[k] = itemOrder
+		}
+	}
+	return order
+}
+
+// plainValue returns a copy of a rendered value with mappings converted to maps.
+func plainValue(value any) any {
+	switch v := value.(type) {
+	case *mapping:
+		return plainValue(v.values)
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for k, item := range v {
+			result[k] = plainValue(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = plainValue(item)
+		}
+		return result
+	}
+	return value
+}
+
+// sourceNode returns the content of a document node, resolving aliases.
+func sourceNode(node *yaml.Node) *yaml.Node {
+	for node != nil {
+		switch {
+		case node.Kind == yaml.DocumentNode && len(node.Content) > 0:
+			node = node.Content[0]
+		case node.Kind == yaml.AliasNode:
+			node = node.Alias
+		default:
+			return node
+		}
+	}
+	return nil
+}
+
+// sourceKeys returns the keys of m in the order of its mapping node.
+// Keys not in the node are sorted and placed last.
+func sourceKeys[V any](node *yaml.Node, m map[string]V) []string {
+	var keys []string
+	if node = sourceNode(node); node != nil && node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keys = append(keys, node.Content[i].Value)
+		}
+	}
+	return orderKeys(keys, m)
+}
+
+// sourceValue returns the node of the value of a key in a mapping node.
+func sourceValue(node *yaml.Node, key string) *yaml.Node {
+	if node = sourceNode(node); node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// sourceItem returns the node of an item in a sequence node.
+func sourceItem(node *yaml.Node, i int) *yaml.Node {
+	if node = sourceNode(node); node == nil || node.Kind != yaml.SequenceNode || i >= len(node.Content) {
+		return nil
+	}
+	return node.Content[i]
+}
+
+// insertKeys returns keys with the keys of inserted placed at the position
+// of key. Inserted keys already in keys keep their position.
+func insertKeys(keys []string, key string, inserted []string) []string {
+	i := slices.Index(keys, key)
+	if i < 0 {
+		return append(keys, inserted...)
+	}
+	var added []string
+	for _, k := range inserted {
+		if !slices.Contains(keys, k) {
+			added = append(added, k)
+		}
+	}
+	return slices.Concat(keys[:i], added, keys[i+1:])
+}
+
+// placeKeys returns keys with the keys of placed moved to the position of
+// key in the source keys, before the keys following it.
+func placeKeys(keys, source []string, key string, placed []string) []string {
+	keys = slices.DeleteFunc(slices.Clone(keys), func(k string) bool {
+		return slices.Contains(placed, k)
+	})
+	i := len(keys)
+	if at := slices.Index(source, key); at >= 0 {
+		for _, k := range source[at+1:] {
+			if j := slices.Index(keys, k); j >= 0 {
+				i = j
+				break
+			}
+		}
+	}
+	return slices.Concat(keys[:i], placed, keys[i:])
+}
+
+// Marshal encodes documents as a YAML stream, separated by `---`, with map
+// keys in the order of the documents, see Document.
+func (e *Expr) Marshal(docs []Document) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	for i, doc := range docs {
+		node, err := doc.order.node(doc.values)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding document %d: %w", i, err)
+		}
+		if err := enc.Encode(node); err != nil {
+			return nil, fmt.Errorf("error encoding document %d: %w", i, err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
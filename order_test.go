@@ -0,0 +1,150 @@
+package yamlexpr_test
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+func TestExpr_Marshal(t *testing.T) {
+	fs := fstest.MapFS{
+		"_base.yaml": {Data: []byte("version: 2\nkind: Service\n")},
+		"service.yaml": {Data: []byte(`name: api
+include: _base.yaml
+zone: eu
+ports:
+  - for: port in [80, 443]
+    protocol: tcp
+    port: ${port}
+`)},
+	}
+
+	want := `name: api
+version: 2
+kind: Service
+zone: eu
+ports:
+  - protocol: tcp
+    port: 80
+  - protocol: tcp
+    port: 443
+`
+
+	e := yamlexpr.New(fs)
+	for range 10 {
+		docs, err := e.Load("service.yaml")
+		require.NoError(t, err)
+
+		out, err := e.Marshal(docs)
+		require.NoError(t, err)
+		require.Equal(t, want, string(out))
+	}
+}
+
+func TestExpr_Marshal_SiblingMaps(t *testing.T) {
+	fs := fstest.MapFS{
+		"app.yaml": {Data: []byte(`a: {x: 1, y: 2}
+b: {y: 3, x: 4}
+c:
+  y: 5
+  z:
+    if: false
+  x: 6
+jobs:
+  - name: build
+    matrix:
+      os: [linux]
+      arch: [arm64]
+    image: alpine
+`)},
+	}
+	want := `a:
+  x: 1
+  y: 2
+b:
+  y: 3
+  x: 4
+c:
+  y: 5
+  x: 6
+jobs:
+  - name: build
+    os: linux
+    arch: arm64
+    image: alpine
+`
+
+	e := yamlexpr.New(fs)
+	docs, err := e.Load("app.yaml")
+	require.NoError(t, err)
+
+	out, err := e.Marshal(docs)
+	require.NoError(t, err)
+	require.Equal(t, want, string(out))
+
+	// A copy of the document encodes in order with the yaml package
+	doc := docs[0]
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	require.NoError(t, enc.Encode(doc))
+	require.Equal(t, want, buf.String())
+	require.Equal(t, []string{"a", "b", "c", "jobs"}, doc.Keys())
+}
+
+func TestExpr_Marshal_FileOrder(t *testing.T) {
+	fs := fstest.MapFS{
+		"a.yaml": {Data: []byte("x:\n  b: 1\n  a: 2\n")},
+		"b.yaml": {Data: []byte("y:\n  a: 3\n  b: 4\n")},
+	}
+	want := map[string]string{
+		"a.yaml": "x:\n  b: 1\n  a: 2\n",
+		"b.yaml": "y:\n  a: 3\n  b: 4\n",
+	}
+	wantQuery := map[string]string{
+		"a.yaml": "b: 1\na: 2\n",
+		"b.yaml": "a: 3\nb: 4\n",
+	}
+
+	for _, files := range [][]string{{"a.yaml", "b.yaml"}, {"b.yaml", "a.yaml"}} {
+		e := yamlexpr.New(fs)
+		var loaded [][]yamlexpr.Document
+		for _, file := range files {
+			docs, err := e.Load(file)
+			require.NoError(t, err)
+			loaded = append(loaded, docs)
+		}
+
+		for i, file := range files {
+			out, err := e.Marshal(loaded[i])
+			require.NoError(t, err)
+			require.Equal(t, want[file], string(out), file)
+
+			value, err := e.Query(loaded[i][0], "x ?? y")
+			require.NoError(t, err)
+			out, err = e.MarshalValue(value)
+			require.NoError(t, err)
+			require.Equal(t, wantQuery[file], string(out), file)
+		}
+	}
+}
+
+func TestExpr_Marshal_Documents(t *testing.T) {
+	e := yamlexpr.New(nil)
+
+	docs, err := e.Parse(map[string]any{
+		"for":  "env in ['dev', 'prod']",
+		"name": "${env}",
+		"id":   "app-${env}",
+	})
+	require.NoError(t, err)
+
+	out, err := e.Marshal(docs)
+	require.NoError(t, err)
+	require.Equal(t, "id: app-dev\nname: dev\n---\nid: app-prod\nname: prod\n", string(out))
+}
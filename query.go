@@ -0,0 +1,67 @@
+package yamlexpr
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/titpetric/yamlexpr/interpolation"
+	"github.com/titpetric/yamlexpr/stack"
+)
+
+// Query evaluates a query against a rendered document and returns the result.
+//
+// The query is a path like "spec.replicas" or "services[0].name", resolved
+// in the document, or an expression evaluated with the document keys as
+// variables, e.g. `len(services)` or `services | map(.name)`. The query "."
+// returns the whole document. Expressions may use the functions configured
+// for the Expr. Maps in the result are returned as a Document, with the key
+// order of doc.
+func (e *Expr) Query(doc Document, query string) (any, error) {
+	query = strings.TrimSpace(query)
+	if query == "." {
+		return doc, nil
+	}
+
+	st := stack.NewStack(doc.values)
+	if interpolation.IsPath(query) {
+		if val, ok := st.Resolve(query); ok {
+			return withDocumentOrder(val, doc), nil
+		}
+	}
+
+	val, err := e.evaluator.Eval(query, st)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating query '%s': %w", query, err)
+	}
+	return withDocumentOrder(val, doc), nil
+}
+
+// withDocumentOrder returns a query result with the key order of the
+// document it was queried from. Values not in the document have sorted keys.
+func withDocumentOrder(value any, doc Document) any {
+	order, _ := doc.order.lookup(value, doc.values)
+	return order.withOrder(value)
+}
+
+// MarshalValue encodes a value as YAML, with the map keys of Documents in
+// order like Marshal, and other map keys sorted.
+func (e *Expr) MarshalValue(value any) ([]byte, error) {
+	node, err := (*keyOrder)(nil).node(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,52 @@
+package yamlexpr_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+func TestExpr_Query(t *testing.T) {
+	e := yamlexpr.New(nil)
+	doc := yamlexpr.NewDocument(map[string]any{
+		"name": "api",
+		"spec": map[string]any{"replicas": 3},
+		"services": []any{
+			map[string]any{"name": "web", "port": 80},
+			map[string]any{"name": "db", "port": 5432},
+		},
+	})
+
+	tests := []struct {
+		query string
+		want  any
+		err   string
+	}{
+		{query: "name", want: "api"},
+		{query: "spec.replicas", want: 3},
+		{query: "services[1].name", want: "db"},
+		{query: "services.0.port", want: 80},
+		{query: ".", want: doc},
+		{query: "spec", want: yamlexpr.NewDocument(map[string]any{"replicas": 3})},
+		{query: "spec.missing", want: nil},
+		{query: "len(services)", want: 2},
+		{query: "spec.replicas > 1", want: true},
+		{query: "map(services, .name)", want: []any{"web", "db"}},
+		{query: `name + "-" + services[0].name`, want: "api-web"},
+		{query: "nmae", err: "error evaluating query 'nmae'"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			got, err := e.Query(doc, tc.query)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package yamlexpr
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaKey is the root document key selecting the JSON Schema a document is validated against.
+// The value is a path in the filesystem provided to New(). The key is removed from the output.
+// Values with a URL scheme (e.g. https://) are left in the document and are not used for validation.
+const SchemaKey = "$schema"
+
+// schemaURLScheme is the URL scheme used to load schemas from the filesystem.
+const schemaURLScheme = "fs"
+
+// SchemaError reports a document failing JSON Schema validation.
+type SchemaError struct {
+	// Document is the index of the document in the processing result.
+	Document int
+	// Schema is the path of the schema.
+	Schema string
+	// Violations are the failed schema constraints.
+	Violations []SchemaViolation
+}
+
+// SchemaViolation is a failed schema constraint.
+type SchemaViolation struct {
+	// Path is the document path of the invalid value, e.g. "spec.ports[0].port".
+	Path string
+	// Source is the template location of the value, e.g. "deploy.yaml:12", if known.
+	Source string
+	// Message describes the violation.
+	Message string
+}
+
+// Error implements error.
+func (e *SchemaError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "document %d does not match schema %s:", e.Document, e.Schema)
+	for _, v := range e.Violations {
+		sb.WriteString("\n  - ")
+		if v.Path != "" {
+			sb.WriteString(v.Path)
+		} else {
+			sb.WriteString("(root)")
+		}
+		if v.Source != "" {
+			fmt.Fprintf(&sb, " (%s)", v.Source)
+		}
+		sb.WriteString(": ")
+		sb.WriteString(v.Message)
+	}
+	return sb.String()
+}
+
+// schemaSource is the template a document was loaded from.
+type schemaSource struct {
+	filename string
+	node     *yaml.Node
+}
+
+// schemaCache holds schemas compiled from the filesystem.
+type schemaCache struct {
+	mu      sync.Mutex
+	schemas map[string]*jsonschema.Schema
+}
+
+// newSchemaCache returns an empty schemaCache.
+func newSchemaCache() *schemaCache {
+	return &schemaCache{
+		schemas: make(map[string]*jsonschema.Schema),
+	}
+}
+
+// schema returns the compiled schema for a filename.
+func (e *Expr) schema(filename string) (*jsonschema.Schema, error) {
+	e.schemas.mu.Lock()
+	defer e.schemas.mu.Unlock()
+
+	if sch, ok := e.schemas.schemas[filename]; ok {
+		return sch, nil
+	}
+	if e.fs == nil {
+		return nil, fmt.Errorf("error loading schema %s: no filesystem configured", filename)
+	}
+
+	c := jsonschema.NewCompiler()
+	c.UseLoader(jsonschema.SchemeURLLoader{
+		schemaURLScheme: schemaLoader{fs: e.fs},
+	})
+	sch, err := c.Compile(schemaURLScheme + ":///" + path.Clean(filename))
+	if err != nil {
+		return nil, fmt.Errorf("error compiling schema %s: %w", filename, err)
+	}
+
+	e.schemas.schemas[filename] = sch
+	return sch, nil
+}
+
+// schemaLoader loads JSON and YAML schemas from a filesystem.
+type schemaLoader struct {
+	fs fs.FS
+}
+
+// Load implements jsonschema.URLLoader.
+func (l schemaLoader) Load(url string) (any, error) {
+	filename := strings.TrimPrefix(url, schemaURLScheme+":///")
+	data, err := fs.ReadFile(l.fs, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	switch path.Ext(filename) {
+	case ".yaml", ".yml":
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		return doc, nil
+	default:
+		return jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	}
+}
+
+// validate validates documents against their schema.
+// The schema is selected by the SchemaKey document key, or by WithSchema.
+// Documents without a schema are not validated.
+func (e *Expr) validate(docs []Document, source *schemaSource) error {
+	for i, doc := range docs {
+		filename := e.config.Schema
+		if val, ok := doc.values[SchemaKey].(string); ok && !strings.Contains(val, "://") {
+			filename = val
+			delete(doc.values, SchemaKey)
+		}
+		if filename == "" {
+			continue
+		}
+
+		sch, err := e.schema(filename)
+		if err != nil {
+			return err
+		}
+
+		instance := jsonValue(doc.values)
+		err = sch.Validate(instance)
+		if err == nil {
+			continue
+		}
+
+		validationErr, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			return fmt.Errorf("error validating document %d against schema %s: %w", i, filename, err)
+		}
+
+		schemaErr := &SchemaError{
+			Document: i,
+			Schema:   filename,
+		}
+		printer := message.NewPrinter(language.English)
+		for _, leaf := range schemaLeaves(validationErr) {
+			schemaErr.Violations = append(schemaErr.Violations, SchemaViolation{
+				Path:    documentPath(instance, leaf.InstanceLocation),
+				Source:  e.sourceLocation(source, leaf.InstanceLocation),
+				Message: e.Redact(leaf.ErrorKind.LocalizedString(printer)),
+			})
+		}
+		return schemaErr
+	}
+	return nil
+}
+
+// schemaLeaves returns the validation errors without causes, ordered by instance location.
+func schemaLeaves(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	var leaves []*jsonschema.ValidationError
+	var walk func(err *jsonschema.ValidationError)
+	walk = func(err *jsonschema.ValidationError) {
+		if len(err.Causes) == 0 {
+			if _, ok := err.ErrorKind.(*kind.Group); !ok {
+				leaves = append(leaves, err)
+			}
+			return
+		}
+		for _, cause := range err.Causes {
+			walk(cause)
+		}
+	}
+	walk(err)
+
+	sort.SliceStable(leaves, func(i, j int) bool {
+		return strings.Join(leaves[i].InstanceLocation, "/") < strings.Join(leaves[j].InstanceLocation, "/")
+	})
+	return leaves
+}
+
+// documentPath formats an instance location as a document path, e.g. "spec.ports[0]".
+func documentPath(instance any, location []string) string {
+	var sb strings.Builder
+	cur := instance
+	for _, segment := range location {
+		switch v := cur.(type) {
+		case []any:
+			sb.WriteString("[" + segment + "]")
+			if i, err := strconv.Atoi(segment); err == nil && i < len(v) {
+				cur = v[i]
+			}
+		case map[string]any:
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+			sb.WriteString(segment)
+			cur = v[segment]
+		default:
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+			sb.WriteString(segment)
+		}
+	}
+	return sb.String()
+}
+
+// sourceLocation returns the template location of an instance location, e.g. "deploy.yaml:12".
+// The location of the closest template node is returned. Sequences containing
+// directives are not followed, as their items don't match the output.
+func (e *Expr) sourceLocation(s *schemaSource, location []string) string {
+	if s == nil || s.node == nil {
+		return ""
+	}
+
+	node := s.node
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+walk:
+	for _, segment := range location {
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == segment {
+					node = node.Content[i+1]
+					continue walk
+				}
+			}
+			break walk
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i >= len(node.Content) || e.sequenceHasDirectives(node) {
+				break walk
+			}
+			node = node.Content[i]
+		default:
+			break walk
+		}
+	}
+
+	return fmt.Sprintf("%s:%d", s.filename, node.Line)
+}
+
+// sequenceHasDirectives reports whether a sequence contains items with
+// directives, which may expand to a different number of items.
+func (e *Expr) sequenceHasDirectives(node *yaml.Node) bool {
+	for _, item := range node.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			switch item.Content[i].Value {
+			case e.config.ForDirective(), e.config.MatrixDirective(), e.config.IfDirective(), e.config.IncludeDirective():
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonValue converts a processed value to a JSON compatible value for validation.
+func jsonValue(value any) any {
+	switch v := value.(type) {
+	case Document:
+		return jsonValue(v.values)
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for k, item := range v {
+			result[k] = jsonValue(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = jsonValue(item)
+		}
+		return result
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,134 @@
+package yamlexpr_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+func schemaFS() fstest.MapFS {
+	return fstest.MapFS{
+		"schema/service.json": {Data: []byte(`{
+  "type": "object",
+  "required": ["name", "ports"],
+  "properties": {
+    "name": {"type": "string"},
+    "replicas": {"type": "integer", "minimum": 1},
+    "ports": {"type": "array", "items": {"$ref": "port.yaml"}}
+  }
+}`)},
+		"schema/port.yaml": {Data: []byte("type: object\nrequired: [port]\nproperties:\n  port:\n    type: integer\n    maximum: 65535\n")},
+		"service.yaml": {Data: []byte(`name: api
+replicas: ${count}
+count: 0
+ports:
+  - port: 8080
+  - port: 80000
+`)},
+		"services.yaml": {Data: []byte(`$schema: schema/service.json
+for: name in ["api", "web"]
+name: ${name}
+ports:
+  - port: '${name == "web" ? "http" : 8080}'
+`)},
+	}
+}
+
+func TestExpr_WithSchema(t *testing.T) {
+	t.Run("valid document", func(t *testing.T) {
+		e := yamlexpr.New(schemaFS(), yamlexpr.WithSchema("schema/service.json"))
+
+		docs, err := e.Parse(map[string]any{
+			"replicas": 2,
+			"name":     "api-${replicas}",
+			"ports":    []any{map[string]any{"port": 8080}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "api-2", docs[0].Map()["name"])
+	})
+
+	t.Run("violations with source locations", func(t *testing.T) {
+		e := yamlexpr.New(schemaFS(), yamlexpr.WithSchema("schema/service.json"))
+
+		_, err := e.Load("service.yaml")
+		require.Error(t, err)
+
+		var schemaErr *yamlexpr.SchemaError
+		require.True(t, errors.As(err, &schemaErr))
+		require.Equal(t, 0, schemaErr.Document)
+		require.Equal(t, "schema/service.json", schemaErr.Schema)
+		require.Len(t, schemaErr.Violations, 2)
+
+		require.Equal(t, "ports[1].port", schemaErr.Violations[0].Path)
+		require.Equal(t, "service.yaml:6", schemaErr.Violations[0].Source)
+		require.Contains(t, schemaErr.Violations[0].Message, "maximum")
+
+		require.Equal(t, "replicas", schemaErr.Violations[1].Path)
+		require.Equal(t, "service.yaml:2", schemaErr.Violations[1].Source)
+		require.Contains(t, schemaErr.Violations[1].Message, "minimum")
+
+		require.Contains(t, err.Error(), "ports[1].port (service.yaml:6)")
+	})
+
+	t.Run("schema document key", func(t *testing.T) {
+		e := yamlexpr.New(schemaFS())
+
+		_, err := e.Load("services.yaml")
+		require.Error(t, err)
+
+		var schemaErr *yamlexpr.SchemaError
+		require.True(t, errors.As(err, &schemaErr))
+		require.Equal(t, 1, schemaErr.Document)
+		require.Len(t, schemaErr.Violations, 1)
+		require.Equal(t, "ports[0].port", schemaErr.Violations[0].Path)
+		require.Equal(t, "services.yaml:5", schemaErr.Violations[0].Source)
+	})
+
+	t.Run("schema document key is removed", func(t *testing.T) {
+		e := yamlexpr.New(schemaFS())
+
+		docs, err := e.Parse(map[string]any{
+			"$schema": "schema/service.json",
+			"name":    "api",
+			"ports":   []any{},
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"name": "api", "ports": []any{}}, docs[0].Map())
+	})
+
+	t.Run("schema URLs are kept", func(t *testing.T) {
+		e := yamlexpr.New(schemaFS())
+
+		doc := map[string]any{"$schema": "https://json.schemastore.org/github-workflow.json"}
+		docs, err := e.Parse(doc)
+		require.NoError(t, err)
+		require.Equal(t, doc, docs[0].Map())
+	})
+
+	t.Run("template", func(t *testing.T) {
+		e := yamlexpr.New(schemaFS(), yamlexpr.WithSchema("schema/service.json"))
+
+		tpl, err := e.Compile(map[string]any{
+			"name":  "${name}",
+			"ports": []any{},
+		})
+		require.NoError(t, err)
+
+		_, err = tpl.Execute(map[string]any{"name": "api"})
+		require.NoError(t, err)
+
+		_, err = tpl.Execute(map[string]any{"name": 1})
+		require.ErrorContains(t, err, "- name: got number, want string")
+	})
+
+	t.Run("missing schema", func(t *testing.T) {
+		e := yamlexpr.New(schemaFS(), yamlexpr.WithSchema("schema/missing.json"))
+
+		_, err := e.Parse(map[string]any{"name": "api"})
+		require.ErrorContains(t, err, "error compiling schema schema/missing.json")
+	})
+}
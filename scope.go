@@ -0,0 +1,108 @@
+package yamlexpr
+
+import (
+	"fmt"
+
+	"github.com/titpetric/yamlexpr/interpolation"
+	"github.com/titpetric/yamlexpr/stack"
+)
+
+// Scope is the variable scope of a block of a rendered template: the
+// document root, or an iteration of a for loop or a matrix job.
+type Scope struct {
+	// Path is the document path of the block, e.g. "services[0][1]" for
+	// the second iteration of a for loop in the first services item.
+	// The document root has an empty path.
+	Path string
+	// Steps are the include, condition and iteration events entering the scope, outermost first.
+	Steps []TraceEvent
+
+	expr *Expr
+	ctx  *Context
+}
+
+// Scopes renders the template with vars like Execute, and returns the scope
+// of the document root followed by the scope of each for loop iteration and
+// matrix job, in processing order. Scopes evaluate expressions and conditions
+// with the variables defined in the block, as the template does.
+func (t *Template) Scopes(vars map[string]any) ([]*Scope, error) {
+	scope, err := t.expr.resolveInputs(t.inputs, vars)
+	if err != nil {
+		return nil, err
+	}
+	rootVars := copyValue(t.doc).(map[string]any)
+	root := stack.NewStack(rootVars)
+	if len(scope) > 0 {
+		root.Push(scope)
+	}
+	t.expr.resolveRootVars(rootVars, root)
+
+	e := *t.expr
+	config := *e.config
+	e.config = &config
+	scopes := []*Scope{e.newScope(NewContext(&ContextOptions{Stack: root, Evaluator: e.evaluator}))}
+	config.Tracer = func(ctx *Context, event TraceEvent) {
+		if t.expr.config.Tracer != nil {
+			t.expr.config.Tracer(ctx, event)
+		}
+		if event.Kind == TraceFor || event.Kind == TraceMatrix {
+			scopes = append(scopes, e.newScope(ctx.WithTrace(event)))
+		}
+	}
+
+	if _, err := e.render(t, vars); err != nil {
+		return nil, err
+	}
+	return scopes, nil
+}
+
+// newScope returns the scope of ctx. The stack is copied, as scopes are
+// cleared when processing leaves them.
+func (e *Expr) newScope(ctx *Context) *Scope {
+	st := stack.NewStack(ctx.Stack().All())
+	return &Scope{
+		Path:  ctx.Path(),
+		Steps: e.redactEvents(ctx.Trace().Events()),
+		expr:  e,
+		ctx: NewContext(&ContextOptions{
+			Stack:     st,
+			Evaluator: ctx.Evaluator(),
+		}).WithPath(ctx.Path()),
+	}
+}
+
+// Stack returns the variable stack of the scope.
+func (s *Scope) Stack() *stack.Stack {
+	return s.ctx.Stack()
+}
+
+// Vars returns the variables defined in the scope.
+func (s *Scope) Vars() map[string]any {
+	return s.ctx.Stack().All()
+}
+
+// Eval evaluates an expression like `len(services)` in the scope, or
+// interpolates a string like `${name}-${env}`, and returns the typed result.
+func (s *Scope) Eval(input string) (any, error) {
+	if interpolation.ContainsInterpolation(input) {
+		return s.ctx.Evaluator().InterpolateValueWithContext(input, s.ctx.Stack(), s.Path)
+	}
+	val, err := s.ctx.Evaluator().Eval(input, s.ctx.Stack())
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating '%s'%s: %w", input, s.at(), err)
+	}
+	return val, nil
+}
+
+// Condition evaluates an if condition in the scope, like the if directive.
+func (s *Scope) Condition(condition string) (bool, error) {
+	return s.expr.evaluateIf(s.ctx, condition, directivePath(s.ctx, s.expr.config.IfDirective()))
+}
+
+// at returns the " at <path>" suffix of errors, empty at the document root.
+func (s *Scope) at() string {
+	if s.Path == "" {
+		return ""
+	}
+	return " at " + s.Path
+}
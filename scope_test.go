@@ -0,0 +1,85 @@
+package yamlexpr_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+func TestTemplate_Scopes(t *testing.T) {
+	e := yamlexpr.New(nil)
+	tpl, err := e.Compile(map[string]any{
+		"inputs": map[string]any{"env": map[string]any{"default": "dev"}},
+		"name":   "app",
+		"services": []any{
+			map[string]any{
+				"for":   "svc in [\"api\", \"web\"]",
+				"name":  "${svc}-${env}",
+				"ports": []any{map[string]any{"for": "port in [80, 443]", "port": "${port}"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	scopes, err := tpl.Scopes(map[string]any{"env": "prod"})
+	require.NoError(t, err)
+
+	paths := make([]string, len(scopes))
+	for i, s := range scopes {
+		paths[i] = s.Path
+	}
+	require.Equal(t, []string{
+		"",
+		"services[0][0]",
+		"services[0][0].ports[0][0]",
+		"services[0][0].ports[0][1]",
+		"services[0][1]",
+		"services[0][1].ports[0][0]",
+		"services[0][1].ports[0][1]",
+	}, paths)
+
+	root := scopes[0]
+	require.Empty(t, root.Steps)
+	val, err := root.Eval("name + \"-\" + env")
+	require.NoError(t, err)
+	require.Equal(t, "app-prod", val)
+	_, err = root.Eval("svc")
+	require.ErrorContains(t, err, "error evaluating 'svc'")
+
+	scope := scopes[6]
+	require.Len(t, scope.Steps, 2)
+	require.Equal(t, yamlexpr.TraceFor, scope.Steps[0].Kind)
+	require.Equal(t, map[string]any{"port": 443}, scope.Steps[1].Vars)
+	require.Equal(t, "web", scope.Vars()["svc"])
+
+	tests := []struct {
+		input string
+		want  any
+	}{
+		{input: "svc", want: "web"},
+		{input: "port * 2", want: 886},
+		{input: "${svc}:${port}", want: "web:443"},
+		{input: "${port}", want: 443},
+	}
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			val, err := scope.Eval(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, val)
+		})
+	}
+
+	ok, err := scope.Condition(`svc == "web" && port > 80`)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = scope.Condition("${port} == 80")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	v, ok := scope.Stack().Resolve("name")
+	require.True(t, ok)
+	require.Equal(t, "app", v)
+}
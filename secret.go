@@ -0,0 +1,61 @@
+package yamlexpr
+
+// Redact replaces secret values resolved by the Expr in text with `***`.
+// Without WithSecrets, text is returned unchanged.
+func (e *Expr) Redact(text string) string {
+	return e.config.Secrets.Redact(text)
+}
+
+// redactError returns err with secret values masked in its message.
+// The original error remains available with errors.Unwrap and errors.As.
+func (e *Expr) redactError(err error) error {
+	if err == nil || e.config.Secrets == nil {
+		return err
+	}
+	msg := e.Redact(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{err: err, msg: msg}
+}
+
+// redactedError is an error with secret values masked in its message.
+type redactedError struct {
+	err error
+	msg string
+}
+
+// Error implements error.
+func (r *redactedError) Error() string {
+	return r.msg
+}
+
+// Unwrap returns the original error.
+func (r *redactedError) Unwrap() error {
+	return r.err
+}
+
+// redactValue returns a copy of value with secret values masked in strings.
+// Without WithSecrets, value is returned unchanged.
+func (e *Expr) redactValue(value any) any {
+	if e.config.Secrets == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case string:
+		return e.Redact(v)
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for k, item := range v {
+			result[k] = e.redactValue(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = e.redactValue(item)
+		}
+		return result
+	}
+	return value
+}
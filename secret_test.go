@@ -0,0 +1,104 @@
+package yamlexpr_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+func TestExpr_WithSecrets(t *testing.T) {
+	secrets := fstest.MapFS{
+		"db/password": {Data: []byte("hunter2\n")},
+		"db/port":     {Data: []byte("not-a-port")},
+	}
+	e := yamlexpr.New(nil, yamlexpr.WithSecrets(&yamlexpr.FileSecrets{FS: secrets}))
+
+	t.Run("secrets are written in full", func(t *testing.T) {
+		docs, err := e.Parse(map[string]any{
+			"dsn":      `postgres://app:${secret("db/password")}@db/app`,
+			"password": `${secret("db/password")}`,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "postgres://app:hunter2@db/app", docs[0].Map()["dsn"])
+		require.Equal(t, "hunter2", docs[0].Map()["password"])
+	})
+
+	t.Run("secrets are masked in errors", func(t *testing.T) {
+		_, err := e.Parse(map[string]any{
+			"port": `${secret("db/port") | int}`,
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `cannot convert "***" to int`)
+		require.NotContains(t, err.Error(), "not-a-port")
+	})
+
+	t.Run("redact", func(t *testing.T) {
+		require.Equal(t, "password=***", e.Redact("password=hunter2"))
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		_, err := e.Parse(map[string]any{
+			"token": `${secret("api/token")}`,
+		})
+		require.ErrorContains(t, err, "secret 'api/token' not found")
+	})
+}
+
+func TestExpr_WithSecrets_Providers(t *testing.T) {
+	t.Run("env", func(t *testing.T) {
+		provider := &yamlexpr.EnvSecrets{
+			Prefix: "SECRET_",
+			Lookup: func(name string) (string, bool) {
+				if name == "SECRET_DB_PASSWORD" {
+					return "hunter2", true
+				}
+				return "", false
+			},
+		}
+
+		val, err := provider.Secret("db/password")
+		require.NoError(t, err)
+		require.Equal(t, "hunter2", val)
+
+		_, err = provider.Secret("db.user")
+		require.ErrorContains(t, err, "environment variable SECRET_DB_USER is not set")
+	})
+
+	t.Run("func", func(t *testing.T) {
+		calls := 0
+		provider := yamlexpr.SecretProviderFunc(func(name string) (string, error) {
+			calls++
+			if name == "token" {
+				return "s3cr3t-token", nil
+			}
+			return "", errors.New("access denied")
+		})
+		e := yamlexpr.New(nil, yamlexpr.WithSecrets(provider))
+
+		docs, err := e.Parse(map[string]any{
+			"a": `${secret("token")}`,
+			"b": `${secret("token")}`,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "s3cr3t-token", docs[0].Map()["b"])
+		require.Equal(t, 1, calls, "secrets are cached")
+
+		_, err = e.Parse(map[string]any{"c": `${secret("other")}`})
+		require.ErrorContains(t, err, "access denied")
+	})
+
+	t.Run("overlapping secrets", func(t *testing.T) {
+		provider := yamlexpr.SecretProviderFunc(func(name string) (string, error) {
+			return map[string]string{"a": "abc", "b": "abcdef"}[name], nil
+		})
+		e := yamlexpr.New(nil, yamlexpr.WithSecrets(provider))
+
+		_, err := e.Parse(map[string]any{"v": `${secret("a")}${secret("b")}`})
+		require.NoError(t, err)
+		require.Equal(t, "*** and ***", e.Redact("abcdef and abc"))
+	})
+}
@@ -0,0 +1,165 @@
+package yamlexpr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/titpetric/yamlexpr/interpolation"
+	"github.com/titpetric/yamlexpr/stack"
+)
+
+// evaluateIf evaluates an if directive, strictly if the config enables strict mode.
+func (e *Expr) evaluateIf(ctx *Context, condition any, path string) (bool, error) {
+	if e.config.Strict {
+		return evaluateStrictCondition(ctx.Evaluator(), condition, ctx.Stack(), path)
+	}
+	return evaluateCondition(ctx.Evaluator(), condition, ctx.Stack(), path)
+}
+
+// evaluateStrictCondition evaluates an if condition without implicit coercions.
+// The condition must be a boolean, or an expression evaluating to a boolean.
+// Interpolated conditions are evaluated as is, without quoting comparisons.
+func evaluateStrictCondition(ev *interpolation.Evaluator, condition any, st *stack.Stack, path string) (bool, error) {
+	pathCtx := ""
+	if path != "" {
+		pathCtx = fmt.Sprintf(" at %s", path)
+	}
+
+	var (
+		result any
+		err    error
+	)
+	switch v := condition.(type) {
+	case bool:
+		return v, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return false, fmt.Errorf("empty condition%s", pathCtx)
+		}
+
+		// Single interpolations like "${item.active}" return the native value
+		if interpolation.ContainsInterpolation(v) {
+			result, err = ev.InterpolateConditionWithContext(v, st, path)
+			if err != nil {
+				return false, err
+			}
+			if segments := interpolation.Scan(v); len(segments) == 1 && segments[0].Expr {
+				break
+			}
+			v = fmt.Sprintf("%v", result)
+		}
+
+		program, err := ev.CompileWithContext(v, st, path)
+		if err != nil {
+			return false, fmt.Errorf("error compiling expression '%s'%s: %w", v, pathCtx, err)
+		}
+		result, err = program.Run()
+		if err != nil {
+			return false, fmt.Errorf("error evaluating expression '%s'%s: %w", v, pathCtx, err)
+		}
+	default:
+		return false, fmt.Errorf("unsupported condition type: %T%s", condition, pathCtx)
+	}
+
+	ok, isBool := result.(bool)
+	if !isBool {
+		return false, fmt.Errorf("condition must evaluate to a boolean, got %T%s", result, pathCtx)
+	}
+	return ok, nil
+}
+
+// checkDirectiveKeys reports keys of m that look like misspelled directives.
+// Keys are checked in sorted order for a stable error.
+func (e *Expr) checkDirectiveKeys(m map[string]any, path string) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if directive, ok := e.misspelledDirective(k, path == ""); ok {
+			return fmt.Errorf("unknown key '%s' at %s, did you mean '%s'?", k, joinPath(path, k), directive)
+		}
+	}
+	return nil
+}
+
+// misspelledDirective returns the directive a key is likely a misspelling of.
+//
+// A key is a misspelling if it is one deletion, substitution or transposition
+// away from a directive. Keys with an inserted character, like the plural
+// `includes`, are often regular keys and aren't reported. Directives of up
+// to three characters only match transpositions (e.g. `fro`), so keys like
+// `id` aren't reported as misspellings of `if`. The inputs directive is only
+// matched in the document root, where it is declared.
+func (e *Expr) misspelledDirective(key string, root bool) (string, bool) {
+	directives := []string{
+		e.config.IfDirective(),
+		e.config.ForDirective(),
+		e.config.IncludeDirective(),
+		e.config.MatrixDirective(),
+	}
+	if root {
+		directives = append(directives, e.config.InputsDirective())
+	}
+	directives = append(directives, e.config.HandlerOrder...)
+
+	for _, directive := range directives {
+		if key == directive {
+			return "", false
+		}
+	}
+
+	for _, directive := range directives {
+		if len(key) > len(directive) || editDistance(key, directive) != 1 {
+			continue
+		}
+		if len(directive) <= 3 && !isTransposition(key, directive) {
+			continue
+		}
+		return directive, true
+	}
+	return "", false
+}
+
+// isTransposition reports whether a and b differ by swapping two adjacent characters.
+func isTransposition(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i+1 < len(a); i++ {
+		if a[i] != b[i] {
+			return a[i] == b[i+1] && a[i+1] == b[i] && a[i+2:] == b[i+2:]
+		}
+	}
+	return false
+}
+
+// editDistance returns the optimal string alignment distance between a and b,
+// counting insertions, deletions, substitutions and adjacent transpositions.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,137 @@
+package yamlexpr_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+func TestExpr_WithStrict(t *testing.T) {
+	e := yamlexpr.New(nil, yamlexpr.WithStrict())
+
+	t.Run("boolean conditions", func(t *testing.T) {
+		docs, err := e.Parse(map[string]any{
+			"enabled": true,
+			"env":     "prod",
+			"a":       map[string]any{"if": true, "v": 1},
+			"b":       map[string]any{"if": "${enabled}", "v": 2},
+			"c":       map[string]any{"if": "env == 'prod'", "v": 3},
+			"d":       map[string]any{"if": "'${env}' == 'dev'", "v": 4},
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"v": 1}, docs[0].Map()["a"])
+		require.Equal(t, map[string]any{"v": 2}, docs[0].Map()["b"])
+		require.Equal(t, map[string]any{"v": 3}, docs[0].Map()["c"])
+		require.NotContains(t, docs[0].Map(), "d")
+	})
+
+	tests := []struct {
+		name    string
+		doc     map[string]any
+		err     string
+		lenient bool
+	}{
+		{
+			name:    "yes is not coerced",
+			lenient: true,
+			doc:     map[string]any{"a": map[string]any{"if": "yes", "v": 1}},
+			err:     "error compiling expression 'yes' at a.if",
+		},
+		{
+			name:    "empty condition",
+			lenient: true,
+			doc:     map[string]any{"a": map[string]any{"if": "", "v": 1}},
+			err:     "empty condition at a.if",
+		},
+		{
+			name: "numeric condition",
+			doc:  map[string]any{"a": map[string]any{"if": 1, "v": 1}},
+			err:  "unsupported condition type: int at a.if",
+		},
+		{
+			name: "non-boolean result",
+			doc:  map[string]any{"name": "x", "a": map[string]any{"if": "name", "v": 1}},
+			err:  "condition must evaluate to a boolean, got string at a.if",
+		},
+		{
+			name:    "interpolated bare words are not quoted",
+			lenient: true,
+			doc:     map[string]any{"env": "prod", "a": map[string]any{"if": "${env} == prod", "v": 1}},
+			err:     "error compiling expression 'prod == prod' at a.if",
+		},
+		{
+			name:    "misspelled for",
+			lenient: true,
+			doc:     map[string]any{"items": []any{map[string]any{"fro": "x in xs", "v": 1}}},
+			err:     "unknown key 'fro' at items[0].fro, did you mean 'for'?",
+		},
+		{
+			name: "misspelled include",
+			doc:  map[string]any{"inclde": "base.yaml"},
+			err:  "unknown key 'inclde' at inclde, did you mean 'include'?",
+		},
+		{
+			name:    "undefined matrix variable",
+			lenient: true,
+			doc: map[string]any{"jobs": []any{map[string]any{
+				"matrix": map[string]any{"os": []any{"linux"}},
+				"xcode":  "${xcode}",
+			}}},
+			err: "undefined variable 'xcode'",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := e.Parse(tc.doc)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+
+			if tc.lenient {
+				_, err = yamlexpr.New(nil).Parse(tc.doc)
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("similar keys", func(t *testing.T) {
+		_, err := e.Parse(map[string]any{
+			"id":      1,
+			"of":      2,
+			"fi":      3,
+			"metrics": 4,
+		})
+		require.EqualError(t, err, "unknown key 'fi' at fi, did you mean 'if'?")
+
+		_, err = e.Parse(map[string]any{"id": 1, "of": 2, "metrics": 4, "form": 5})
+		require.NoError(t, err)
+	})
+
+	t.Run("inputs", func(t *testing.T) {
+		_, err := e.Parse(map[string]any{"input": map[string]any{}})
+		require.EqualError(t, err, "unknown key 'input' at input, did you mean 'inputs'?")
+
+		_, err = e.Compile(map[string]any{"input": map[string]any{}})
+		require.EqualError(t, err, "unknown key 'input' at input, did you mean 'inputs'?")
+
+		_, err = e.Parse(map[string]any{"step": map[string]any{"input": "x"}})
+		require.NoError(t, err)
+	})
+
+	t.Run("inserted characters", func(t *testing.T) {
+		_, err := e.Parse(map[string]any{
+			"includes": []any{"a.yaml"},
+			"matrixes": 1,
+			"iff":      2,
+			"forr":     3,
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("compile", func(t *testing.T) {
+		_, err := e.Compile(map[string]any{"matirx": map[string]any{}})
+		require.EqualError(t, err, "unknown key 'matirx' at matirx, did you mean 'matrix'?")
+	})
+}
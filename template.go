@@ -0,0 +1,292 @@
+package yamlexpr
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/titpetric/yamlexpr/interpolation"
+	"github.com/titpetric/yamlexpr/stack"
+)
+
+// Template is a document compiled for repeated execution.
+//
+// Compiling validates the directives in the document, parses for and matrix
+// expressions and compiles interpolations and conditions ahead of time.
+// Executing a template renders the document with a set of input variables.
+// A Template is safe for concurrent use.
+type Template struct {
+	expr *Expr
+	// doc is the document, its root-level keys are available as variables
+	doc map[string]any
+	// tree is the document rendered by Execute, with parsed directives
+	tree   map[string]any
+	inputs []Input
+	source *schemaSource
+}
+
+// compiledFor is a for directive parsed by Compile.
+type compiledFor struct {
+	// expr is the for expression, e.g. "item in items"
+	expr string
+	loop *ForLoopExpr
+}
+
+// compiledMatrix is a matrix directive without interpolations, parsed by Compile.
+type compiledMatrix struct {
+	directive *MatrixDirective
+}
+
+// Compile compiles a document into a Template.
+// It returns an error if a directive or expression in the document is invalid.
+func (e *Expr) Compile(doc map[string]any) (*Template, error) {
+	return e.compile(doc, nil)
+}
+
+// compile compiles a document into a Template. The document node, if any,
+// orders the declared inputs and the keys of rendered documents.
+func (e *Expr) compile(doc map[string]any, node *yaml.Node) (*Template, error) {
+	inputs, err := e.parseInputs(doc[e.config.InputsDirective()], node)
+	if err != nil {
+		return nil, err
+	}
+
+	root, _ := copyValue(withoutKey(doc, e.config.InputsDirective())).(map[string]any)
+	tree, _ := copyValue(root).(map[string]any)
+	if err := e.compileValue(tree, ""); err != nil {
+		return nil, err
+	}
+	return &Template{
+		expr:   e,
+		doc:    root,
+		tree:   tree,
+		inputs: inputs,
+	}, nil
+}
+
+// LoadTemplate loads a YAML file and compiles it into a Template.
+// The filename is resolved relative to the filesystem provided to New().
+func (e *Expr) LoadTemplate(filename string) (*Template, error) {
+	node, doc, err := e.readDocument(filename)
+	if err != nil {
+		return nil, err
+	}
+	return e.compileFile(filename, node, doc)
+}
+
+// CompileYAML parses YAML data and compiles it into a Template, like LoadTemplate.
+// The filename is used in errors and source locations, includes are resolved
+// relative to the filesystem provided to New().
+func (e *Expr) CompileYAML(filename string, data []byte) (*Template, error) {
+	node, doc, err := e.decodeDocument(filename, data)
+	if err != nil {
+		return nil, err
+	}
+	return e.compileFile(filename, node, doc)
+}
+
+// compileFile compiles a document parsed from a file.
+func (e *Expr) compileFile(filename string, node *yaml.Node, doc map[string]any) (*Template, error) {
+	t, err := e.compile(doc, node)
+	if err != nil {
+		return nil, fmt.Errorf("error compiling file %s: %w", filename, err)
+	}
+	t.source = &schemaSource{filename: filename, node: node}
+	return t, nil
+}
+
+// Execute renders the template with vars.
+// Root-level keys of the document are available as variables, and vars take precedence over them.
+// Root-level strings referencing other root keys, like `${artifact}`, resolve to the interpolated value.
+// Vars are validated against the declared inputs, see Input.
+// Returns a slice of Documents, see Expr.Parse.
+func (t *Template) Execute(vars map[string]any) ([]Document, error) {
+	return t.expr.render(t, vars)
+}
+
+// compileValue validates directives and compiles expressions in a value.
+// Parsed for and matrix directives replace their values in the maps.
+func (e *Expr) compileValue(value any, path string) error {
+	switch v := value.(type) {
+	case map[string]any:
+		return e.compileMap(v, path)
+	case []any:
+		for i, item := range v {
+			if err := e.compileValue(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case string:
+		return e.compileString(v, path)
+	default:
+		return nil
+	}
+}
+
+// compileMap validates the directives of a map and compiles its values.
+func (e *Expr) compileMap(m map[string]any, path string) error {
+	if e.config.Strict {
+		if err := e.checkDirectiveKeys(m, path); err != nil {
+			return err
+		}
+	}
+
+	for k, v := range m {
+		keyPath := joinPath(path, k)
+
+		var err error
+		switch k {
+		case e.config.IncludeDirective():
+			err = compileInclude(v, keyPath)
+		case e.config.ForDirective():
+			m[k], err = e.compileFor(v, keyPath)
+		case e.config.MatrixDirective():
+			m[k], err = e.compileMatrix(v, keyPath)
+		case e.config.IfDirective():
+			err = e.compileCondition(v, keyPath)
+		default:
+			err = e.compileValue(v, keyPath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileInclude validates an include directive.
+func compileInclude(incl any, path string) error {
+	switch v := incl.(type) {
+	case string:
+		return nil
+	case []any:
+		for i, f := range v {
+			if _, ok := f.(string); !ok {
+				return fmt.Errorf("include must be a string or list of strings, got %T at %s[%d]", f, path, i)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("include must be a string or list of strings, got %T at %s", incl, path)
+	}
+}
+
+// compileFor parses a for directive and compiles its source expression.
+// It returns the parsed directive for string expressions.
+func (e *Expr) compileFor(forExpr any, path string) (any, error) {
+	switch v := forExpr.(type) {
+	case []any:
+		return forExpr, nil
+	case string:
+		loopVars, err := parseForExpr(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid for expression '%s' at %s: %w", v, path, err)
+		}
+		if !forSourcePattern.MatchString(loopVars.Source) {
+			if err := e.evaluator.Precompile(loopVars.Source); err != nil {
+				return nil, fmt.Errorf("error compiling for source '%s' at %s: %w", loopVars.Source, path, err)
+			}
+		}
+		return &compiledFor{expr: v, loop: loopVars}, nil
+	default:
+		return nil, fmt.Errorf("for: expected array or string expression, got %T at %s", forExpr, path)
+	}
+}
+
+// compileMatrix validates a matrix directive and compiles its interpolations.
+// It returns the parsed directive if the matrix has no interpolations.
+func (e *Expr) compileMatrix(matrixValue any, path string) (any, error) {
+	switch v := matrixValue.(type) {
+	case string:
+		if !interpolation.ContainsInterpolation(v) {
+			return nil, fmt.Errorf("matrix must be a map, got %T at %s", matrixValue, path)
+		}
+		return matrixValue, e.compileString(v, path)
+	case map[string]any:
+		static := true
+		values := make(map[string]any, len(v))
+		for k, item := range v {
+			// Matrix values are variables, directives in them are kept as is
+			if err := e.compileValue(copyValue(item), joinPath(path, k)); err != nil {
+				return nil, err
+			}
+			if s, ok := item.(string); ok && interpolation.ContainsInterpolation(s) {
+				static = false
+			}
+			if stack.IsSlice(item) {
+				item = stack.SliceToAny(item)
+			}
+			values[k] = item
+		}
+		md, err := parseMatrixDirective(values)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing matrix at %s: %w", path, err)
+		}
+		if !static {
+			return matrixValue, nil
+		}
+		return &compiledMatrix{directive: md}, nil
+	default:
+		return nil, fmt.Errorf("matrix must be a map, got %T at %s", matrixValue, path)
+	}
+}
+
+// compileCondition validates an if directive and compiles its expression.
+func (e *Expr) compileCondition(condition any, path string) error {
+	switch v := condition.(type) {
+	case bool:
+		return nil
+	case int, int8, int16, int32, int64, float32, float64:
+		if e.config.Strict {
+			return fmt.Errorf("unsupported condition type: %T at %s", condition, path)
+		}
+		return nil
+	case string:
+		switch v {
+		case "true", "false":
+			return nil
+		case "1", "yes", "0", "no", "":
+			if e.config.Strict {
+				break
+			}
+			return nil
+		}
+		// Interpolated conditions are compiled after interpolation
+		if strings.Contains(v, "${") {
+			return e.compileString(v, path)
+		}
+		if err := e.evaluator.Precompile(v); err != nil {
+			return fmt.Errorf("error compiling expression '%s' at %s: %w", v, path, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported condition type: %T at %s", condition, path)
+	}
+}
+
+// compileString compiles the ${...} expressions in a string.
+func (e *Expr) compileString(s string, path string) error {
+	for _, segment := range interpolation.Scan(s) {
+		if !segment.Expr {
+			continue
+		}
+		if err := e.evaluator.Precompile(segment.Text); err != nil {
+			// Variable paths that aren't valid expressions are resolved from the stack
+			if interpolation.IsPath(segment.Text) {
+				continue
+			}
+			return fmt.Errorf("error compiling expression '%s' at %s: %w", segment.Text, path, err)
+		}
+	}
+	return nil
+}
+
+// joinPath appends a key to a document path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
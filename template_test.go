@@ -0,0 +1,348 @@
+package yamlexpr_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+// TestTemplate_Execute tests rendering a compiled template with different variables.
+func TestTemplate_Execute(t *testing.T) {
+	e := yamlexpr.New(nil)
+
+	tpl, err := e.Compile(map[string]any{
+		"name":     "default",
+		"replicas": 1,
+		"service":  "${name}-svc",
+		"scale":    "${replicas * 2}",
+		"debug": map[string]any{
+			"if":    `name == "dev"`,
+			"level": "verbose",
+		},
+		"ports": []any{
+			map[string]any{
+				"for":  "port in ports",
+				"name": "${name}-${port}",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	docs, err := tpl.Execute(map[string]any{
+		"ports": []any{80, 443},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"name":     "default",
+		"replicas": 1,
+		"service":  "default-svc",
+		"scale":    2,
+		"ports": []any{
+			map[string]any{"name": "default-80"},
+			map[string]any{"name": "default-443"},
+		},
+	}, docs[0].Map())
+
+	docs, err = tpl.Execute(map[string]any{
+		"name":     "dev",
+		"replicas": 3,
+		"ports":    []any{8080},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"name":     "default",
+		"replicas": 1,
+		"service":  "dev-svc",
+		"scale":    6,
+		"debug":    map[string]any{"level": "verbose"},
+		"ports": []any{
+			map[string]any{"name": "dev-8080"},
+		},
+	}, docs[0].Map())
+}
+
+// TestTemplate_Execute_RootVariables tests root keys referencing interpolated root keys.
+func TestTemplate_Execute_RootVariables(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  map[string]any
+		vars map[string]any
+		want map[string]any
+	}{
+		{
+			name: "chained",
+			doc: map[string]any{
+				"version":  "1.0",
+				"artifact": "app-${version}.tar.gz",
+				"url":      "https://example.com/${artifact}",
+			},
+			want: map[string]any{
+				"version":  "1.0",
+				"artifact": "app-1.0.tar.gz",
+				"url":      "https://example.com/app-1.0.tar.gz",
+			},
+		},
+		{
+			name: "input takes precedence",
+			doc: map[string]any{
+				"version": "1.0",
+				"image":   "app:${version}",
+				"tag":     "${image}",
+			},
+			vars: map[string]any{"version": "2.0"},
+			want: map[string]any{
+				"version": "1.0",
+				"image":   "app:2.0",
+				"tag":     "app:2.0",
+			},
+		},
+		{
+			name: "typed value",
+			doc: map[string]any{
+				"base":     3,
+				"replicas": "${base * 2}",
+				"total":    "${replicas + 1}",
+			},
+			want: map[string]any{
+				"base":     3,
+				"replicas": 6,
+				"total":    7,
+			},
+		},
+		{
+			name: "loop variable",
+			doc: map[string]any{
+				"for":  "svc in ['api']",
+				"name": "${svc}",
+				"host": "${name}.local",
+			},
+			want: map[string]any{
+				"name": "api",
+				"host": "${svc}.local",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tpl, err := yamlexpr.New(nil).Compile(tc.doc)
+			require.NoError(t, err)
+
+			docs, err := tpl.Execute(tc.vars)
+			require.NoError(t, err)
+			require.Equal(t, []map[string]any{tc.want}, documentMaps(docs))
+		})
+	}
+
+	t.Run("cycle", func(t *testing.T) {
+		tpl, err := yamlexpr.New(nil).Compile(map[string]any{
+			"a": "${b}",
+			"b": "${a}",
+		})
+		require.NoError(t, err)
+
+		docs, err := tpl.Execute(nil)
+		require.NoError(t, err)
+		require.Equal(t, []map[string]any{{"a": "${a}", "b": "${b}"}}, documentMaps(docs))
+	})
+}
+
+// TestTemplate_Execute_Directives tests reusing the for and matrix directives parsed by Compile.
+func TestTemplate_Execute_Directives(t *testing.T) {
+	e := yamlexpr.New(nil)
+
+	doc := map[string]any{
+		"jobs": []any{
+			map[string]any{
+				"matrix": map[string]any{
+					"os":      []any{"linux", "darwin"},
+					"exclude": []any{map[string]any{"os": "darwin"}},
+				},
+				"name": "${os}-${version}",
+			},
+			map[string]any{
+				"matrix": map[string]any{"arch": "${arches}"},
+				"name":   "${arch}",
+			},
+		},
+		"steps": []any{
+			map[string]any{
+				"for":  "(i, step) in steps",
+				"name": "${i}-${step}",
+			},
+		},
+	}
+	tpl, err := e.Compile(doc)
+	require.NoError(t, err)
+	require.Equal(t, "(i, step) in steps", doc["steps"].([]any)[0].(map[string]any)["for"])
+
+	for _, version := range []string{"1", "2"} {
+		vars := map[string]any{
+			"version": version,
+			"arches":  []any{"amd64"},
+			"steps":   []any{"build"},
+		}
+		docs, err := tpl.Execute(vars)
+		require.NoError(t, err)
+		require.Equal(t, []map[string]any{{
+			"jobs": []any{
+				map[string]any{"os": "linux", "name": "linux-" + version},
+				map[string]any{"arch": "amd64", "name": "amd64"},
+			},
+			"steps": []any{
+				map[string]any{"name": "0-build"},
+			},
+		}}, documentMaps(docs))
+	}
+}
+
+func TestTemplate_Concurrent(t *testing.T) {
+	e := yamlexpr.New(nil)
+
+	tpl, err := e.Compile(map[string]any{
+		"for":  "item in items",
+		"name": "${prefix}-${item}",
+		"feature": map[string]any{
+			"if":      "item > 1",
+			"enabled": true,
+		},
+	})
+	require.NoError(t, err)
+
+	const workers = 16
+	results := make([][]yamlexpr.Document, workers)
+	errs := make([]error, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = tpl.Execute(map[string]any{
+				"prefix": fmt.Sprintf("job%d", i),
+				"items":  []any{1, 2},
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < workers; i++ {
+		prefix := fmt.Sprintf("job%d", i)
+		require.NoError(t, errs[i])
+		require.Equal(t, []map[string]any{
+			{"name": prefix + "-1"},
+			{"name": prefix + "-2", "feature": map[string]any{"enabled": true}},
+		}, documentMaps(results[i]))
+	}
+}
+
+// TestExpr_Compile_Errors tests directive validation when compiling a template.
+func TestExpr_Compile_Errors(t *testing.T) {
+	e := yamlexpr.New(nil)
+
+	tests := []struct {
+		name string
+		doc  map[string]any
+		err  string
+	}{
+		{
+			name: "invalid for expression",
+			doc:  map[string]any{"jobs": map[string]any{"for": "items"}},
+			err:  "invalid for expression 'items' at jobs.for",
+		},
+		{
+			name: "invalid for type",
+			doc:  map[string]any{"jobs": map[string]any{"for": 5}},
+			err:  "for: expected array or string expression, got int at jobs.for",
+		},
+		{
+			name: "invalid matrix",
+			doc:  map[string]any{"jobs": map[string]any{"matrix": map[string]any{"include": "linux"}}},
+			err:  "error parsing matrix at jobs.matrix",
+		},
+		{
+			name: "invalid condition",
+			doc:  map[string]any{"debug": map[string]any{"if": "name ==", "level": 1}},
+			err:  "error compiling expression 'name ==' at debug.if",
+		},
+		{
+			name: "invalid interpolation",
+			doc:  map[string]any{"items": []any{"${ 1 + }"}},
+			err:  "error compiling expression '1 +' at items[0]",
+		},
+		{
+			name: "invalid include",
+			doc:  map[string]any{"include": []any{1}},
+			err:  "include must be a string or list of strings, got int at include[0]",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := e.Compile(tc.doc)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+// BenchmarkTemplate_Execute compares rendering a compiled template with parsing the document.
+func BenchmarkTemplate_Execute(b *testing.B) {
+	doc := map[string]any{
+		"name":  "app",
+		"names": []any{"api", "web", "worker"},
+		"services": []any{
+			map[string]any{
+				"for":   "(i, svc) in names",
+				"name":  "${name}-${svc}",
+				"index": "${i}",
+				"ports": []any{
+					map[string]any{
+						"for":  "port in [80, 443]",
+						"port": "${port}",
+					},
+				},
+			},
+		},
+		"jobs": []any{
+			map[string]any{
+				"matrix": map[string]any{
+					"os":   []any{"linux", "darwin", "windows"},
+					"arch": []any{"amd64", "arm64"},
+				},
+				"name": "${os}-${arch}",
+			},
+		},
+	}
+	e := yamlexpr.New(nil)
+	b.Run("Parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_, err := e.Parse(doc)
+			require.NoError(b, err)
+		}
+	})
+
+	tpl, err := e.Compile(doc)
+	require.NoError(b, err)
+	b.Run("Execute", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_, err := tpl.Execute(nil)
+			require.NoError(b, err)
+		}
+	})
+}
+
+// documentMaps returns the values of the documents.
+func documentMaps(docs []yamlexpr.Document) []map[string]any {
+	result := make([]map[string]any, len(docs))
+	for i, doc := range docs {
+		result[i] = doc.Map()
+	}
+	return result
+}
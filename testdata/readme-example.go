@@ -34,8 +34,8 @@ func Example2_ParseAndEvaluateYAMLData() {
 	// Create an Expr evaluator
 	expr := yamlexpr.New(os.DirFS("."))
 
-	// Prepare your YAML data as Document (map[string]any)
-	data := yamlexpr.Document{
+	// Prepare your YAML data as map[string]any
+	data := map[string]any{
 		"name": "production",
 		"env": map[string]any{
 			"debug": false,
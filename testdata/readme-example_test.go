@@ -112,9 +112,9 @@ items:
 		require.NoError(t, err)
 		require.Len(t, docs, 3) // Should expand to 3 documents
 
-		require.Equal(t, "alice", docs[0]["name"])
-		require.Equal(t, "bob", docs[1]["name"])
-		require.Equal(t, "charlie", docs[2]["name"])
+		require.Equal(t, "alice", docs[0].Map()["name"])
+		require.Equal(t, "bob", docs[1].Map()["name"])
+		require.Equal(t, "charlie", docs[2].Map()["name"])
 	})
 
 	t.Run("MatrixExample", func(t *testing.T) {
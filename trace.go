@@ -0,0 +1,209 @@
+package yamlexpr
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/titpetric/yamlexpr/interpolation"
+)
+
+// tracing reports whether processing events are traced.
+func (e *Expr) tracing() bool {
+	return e.config.Tracer != nil || e.recorder != nil
+}
+
+// trace reports an event to the tracer and the explain recorder.
+// The tracer receives the event with secret values redacted.
+func (e *Expr) trace(ctx *Context, event TraceEvent) {
+	if e.config.Tracer != nil {
+		e.config.Tracer(ctx, e.redactEvent(event))
+	}
+	if e.recorder != nil {
+		e.recorder.event(ctx, event)
+	}
+}
+
+// traceScope reports an event scoping ctx and returns ctx scoped by the event.
+// Without tracing, ctx is returned as is.
+func (e *Expr) traceScope(ctx *Context, event TraceEvent) *Context {
+	if !e.tracing() {
+		return ctx
+	}
+	e.trace(ctx, event)
+	return ctx.WithTrace(event)
+}
+
+// traceCondition reports an evaluated if condition. True conditions scope
+// the returned context, false conditions omit the block.
+func (e *Expr) traceCondition(ctx *Context, condition any, path string, result bool) *Context {
+	if !e.tracing() {
+		return ctx
+	}
+	event := TraceEvent{
+		Kind:       TraceCondition,
+		Path:       path,
+		Expression: fmt.Sprint(condition),
+		Result:     result,
+	}
+	if s, ok := condition.(string); ok {
+		event.Vars = e.referencedVars(ctx, s)
+	}
+	if !result {
+		e.trace(ctx, event)
+		return ctx
+	}
+	return e.traceScope(ctx, event)
+}
+
+// traceValue reports an interpolated string value.
+func (e *Expr) traceValue(ctx *Context, s string, value any) {
+	if !e.tracing() || !interpolation.ContainsInterpolation(s) {
+		return
+	}
+	e.trace(ctx, TraceEvent{
+		Kind:       TraceValue,
+		Path:       ctx.Path(),
+		Expression: s,
+		Result:     value,
+		Vars:       e.referencedVars(ctx, s),
+	})
+}
+
+// referencedVars returns the variables referenced by a condition or an
+// interpolated string, with their values.
+func (e *Expr) referencedVars(ctx *Context, s string) map[string]any {
+	if !interpolation.ContainsInterpolation(s) {
+		return ctx.Evaluator().Variables(s, ctx.Stack())
+	}
+	vars := make(map[string]any)
+	for _, segment := range interpolation.Scan(s) {
+		if !segment.Expr {
+			continue
+		}
+		for k, v := range ctx.Evaluator().Variables(segment.Text, ctx.Stack()) {
+			vars[k] = v
+		}
+	}
+	return vars
+}
+
+// directivePath returns the document path of a directive in the block at ctx.
+func directivePath(ctx *Context, directive string) string {
+	if ctx.Path() == "" {
+		return directive
+	}
+	return ctx.Path() + "." + directive
+}
+
+// recorder records the processing contexts of output values for Explain.
+// Output maps and slices are identified by their pointers, as their
+// output paths are only known once processing completes.
+type recorder struct {
+	// maps holds the contexts processing output maps.
+	maps map[unsafe.Pointer]*Context
+	// keys holds the contexts processing the values of output map keys.
+	keys map[unsafe.Pointer]map[string]*Context
+	// items holds the contexts processing the items of output slices.
+	items map[unsafe.Pointer][]*Context
+	// values holds the interpolation events by processing context.
+	values map[*Context]TraceEvent
+	// omitted holds blocks omitted by false conditions.
+	omitted []omission
+	// files holds the YAML nodes of included files.
+	files map[string]*yaml.Node
+	// retained keeps recorded maps and slices alive, so their pointers stay unique.
+	retained []any
+}
+
+// omission is a block omitted by a false condition.
+type omission struct {
+	ctx   *Context
+	event TraceEvent
+}
+
+// newRecorder returns an empty recorder.
+func newRecorder() *recorder {
+	return &recorder{
+		maps:   make(map[unsafe.Pointer]*Context),
+		keys:   make(map[unsafe.Pointer]map[string]*Context),
+		items:  make(map[unsafe.Pointer][]*Context),
+		values: make(map[*Context]TraceEvent),
+		files:  make(map[string]*yaml.Node),
+	}
+}
+
+// pointer returns the identity of a map or a non-empty slice.
+func pointer(v any) unsafe.Pointer {
+	return reflect.ValueOf(v).UnsafePointer()
+}
+
+// event records interpolations and false conditions.
+func (r *recorder) event(ctx *Context, event TraceEvent) {
+	switch event.Kind {
+	case TraceValue:
+		r.values[ctx] = event
+	case TraceCondition:
+		if event.Result == false {
+			r.omitted = append(r.omitted, omission{ctx: ctx, event: event})
+		}
+	}
+}
+
+// recordMap records the context processing an output map.
+func (e *Expr) recordMap(ctx *Context, m map[string]any) {
+	if e.recorder != nil {
+		e.recorder.maps[pointer(m)] = ctx
+		e.recorder.retained = append(e.recorder.retained, m)
+	}
+}
+
+// recordKey records the context processing the value of an output map key.
+func (e *Expr) recordKey(ctx *Context, m map[string]any, key string) {
+	if e.recorder == nil {
+		return
+	}
+	p := pointer(m)
+	if e.recorder.keys[p] == nil {
+		e.recorder.keys[p] = make(map[string]*Context)
+		e.recorder.retained = append(e.recorder.retained, m)
+	}
+	e.recorder.keys[p][key] = ctx
+}
+
+// recordItems records the contexts processing the items of an output slice.
+func (e *Expr) recordItems(ctxs []*Context, s []any) {
+	if e.recorder != nil && len(s) > 0 {
+		e.recorder.items[pointer(s)] = ctxs
+		e.recorder.retained = append(e.recorder.retained, s)
+	}
+}
+
+// recordMerge records the contexts of keys merged from an included map,
+// following the merge rules of mergeRecursive.
+func (e *Expr) recordMerge(dst map[string]any, src any) {
+	srcMap, ok := mapValues(src)
+	if e.recorder == nil || !ok {
+		return
+	}
+	keys := e.recorder.keys[pointer(srcMap)]
+	for k, v := range srcMap {
+		if ctx, ok := keys[k]; ok {
+			e.recordKey(ctx, dst, k)
+		}
+		if vm, ok := mapValues(v); ok {
+			if dm, ok := mapValues(dst[k]); ok && pointer(dm) != pointer(vm) {
+				e.recordMerge(dm, vm)
+			}
+		}
+	}
+}
+
+// recordFile records the YAML node of an included file.
+func (e *Expr) recordFile(filename string, node *yaml.Node) {
+	if e.recorder != nil {
+		e.recorder.files[filename] = node
+	}
+}
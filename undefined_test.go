@@ -0,0 +1,167 @@
+package yamlexpr_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titpetric/yamlexpr"
+)
+
+func TestExpr_WithUndefined(t *testing.T) {
+	doc := func() map[string]any {
+		return map[string]any{
+			"single": "${missing}",
+			"mixed":  "x-${missing}-y",
+			"cond": map[string]any{
+				"if": "missing == 'MISSING'",
+				"v":  1,
+			},
+			"default": "${missing ?? 'fallback'}",
+			"port":    "${config.port ?? 8080}",
+		}
+	}
+
+	tests := []struct {
+		name   string
+		policy yamlexpr.UndefinedFunc
+		want   map[string]any
+	}{
+		{
+			name:   "empty",
+			policy: yamlexpr.UndefinedEmpty,
+			want: map[string]any{
+				"single":  "",
+				"mixed":   "x--y",
+				"default": "fallback",
+				"port":    8080,
+			},
+		},
+		{
+			name:   "keep",
+			policy: yamlexpr.UndefinedKeep,
+			want: map[string]any{
+				"single":  "${missing}",
+				"mixed":   "x-${missing}-y",
+				"default": "fallback",
+				"port":    8080,
+			},
+		},
+		{
+			name:   "null",
+			policy: yamlexpr.UndefinedNull,
+			// Null values are omitted from maps
+			want: map[string]any{
+				"mixed":   "x-null-y",
+				"default": "fallback",
+				"port":    8080,
+			},
+		},
+		{
+			name: "callback",
+			policy: func(u yamlexpr.Undefined) (any, error) {
+				return strings.ToUpper(u.Name), nil
+			},
+			want: map[string]any{
+				"single":  "MISSING",
+				"mixed":   "x-MISSING-y",
+				"cond":    map[string]any{"v": 1},
+				"default": "fallback",
+				"port":    8080,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			e := yamlexpr.New(nil, yamlexpr.WithUndefined(tc.policy))
+			docs, err := e.Parse(doc())
+			require.NoError(t, err)
+			require.Equal(t, tc.want, docs[0].Map())
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		e := yamlexpr.New(nil, yamlexpr.WithUndefined(yamlexpr.UndefinedError))
+
+		_, err := e.Parse(map[string]any{"name": "x-${missing}"})
+		require.EqualError(t, err, "undefined variable 'missing' at name")
+
+		_, err = e.Parse(map[string]any{"a": map[string]any{"if": "missing", "v": 1}})
+		require.ErrorContains(t, err, "undefined variable 'missing'")
+
+		_, err = e.Parse(map[string]any{"a": []any{map[string]any{"for": "item in items", "v": 1}}})
+		require.EqualError(t, err, "undefined variable 'items' at a[0].for")
+	})
+
+	t.Run("for source", func(t *testing.T) {
+		e := yamlexpr.New(nil, yamlexpr.WithUndefined(yamlexpr.UndefinedNull))
+		docs, err := e.Parse(map[string]any{
+			"items": []any{map[string]any{"for": "item in missing", "v": "${item}"}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []any{}, docs[0].Map()["items"])
+	})
+
+	t.Run("keep in conditions", func(t *testing.T) {
+		for _, strict := range []bool{false, true} {
+			opts := []yamlexpr.ConfigOption{yamlexpr.WithUndefined(yamlexpr.UndefinedKeep)}
+			if strict {
+				opts = append(opts, yamlexpr.WithStrict())
+			}
+			e := yamlexpr.New(nil, opts...)
+			docs, err := e.Parse(map[string]any{
+				"name":  "${feature_enabled}",
+				"a":     map[string]any{"if": "feature_enabled == true", "v": 1},
+				"b":     map[string]any{"if": "feature_enabled == nil", "v": 2},
+				"items": []any{map[string]any{"for": "item in missing", "v": "${item}"}},
+			})
+			require.NoError(t, err)
+			require.Equal(t, map[string]any{
+				"name":  "${feature_enabled}",
+				"b":     map[string]any{"v": 2},
+				"items": []any{},
+			}, docs[0].Map())
+		}
+
+		e := yamlexpr.New(nil, yamlexpr.WithUndefined(yamlexpr.UndefinedKeep))
+		docs, err := e.Parse(map[string]any{
+			"a": map[string]any{"if": "feature_enabled", "v": 1},
+			"b": map[string]any{"if": "${feature_enabled}", "v": 2},
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{}, docs[0].Map())
+	})
+
+	t.Run("callback path", func(t *testing.T) {
+		e := yamlexpr.New(nil, yamlexpr.WithUndefined(func(u yamlexpr.Undefined) (any, error) {
+			return nil, errors.New("no " + u.Name + " at " + u.Path)
+		}))
+		_, err := e.Parse(map[string]any{"job": map[string]any{"if": "missing", "v": 1}})
+		require.ErrorContains(t, err, "no missing at job.if")
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		e := yamlexpr.New(nil, yamlexpr.WithUndefined(func(u yamlexpr.Undefined) (any, error) {
+			return nil, errors.New("no " + u.Name + " at " + u.Path)
+		}))
+		_, err := e.Parse(map[string]any{"name": "${missing}"})
+		require.EqualError(t, err, "no missing at name")
+	})
+
+	t.Run("default without policy", func(t *testing.T) {
+		e := yamlexpr.New(nil)
+		docs, err := e.Parse(map[string]any{
+			"label": "${name ?? 'app'}-${env ?? 'dev'}",
+			"upper": "${missing ?? 'x' | upper}",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "app-dev", docs[0].Map()["label"])
+		require.Equal(t, "X", docs[0].Map()["upper"])
+
+		_, err = e.Parse(map[string]any{"name": "${missing}"})
+		require.Error(t, err)
+	})
+}
@@ -6,20 +6,27 @@ import (
 	"strings"
 )
 
+var (
+	// forTuplePattern matches "(var1, var2, ...) in source".
+	forTuplePattern = regexp.MustCompile(`^\((.*?)\)\s+in\s+(.+)$`)
+	// forSimplePattern matches "var in source".
+	forSimplePattern = regexp.MustCompile(`^(\w+)\s+in\s+(.+)$`)
+)
+
 // parseForExpr parses a for loop expression string.
 // Supports:
 //   - "item in items" - iterates over items, binding each to 'item'
 //   - "item in item.subitems" - iterate over nested path
 //   - "(idx, item) in items" - iterates over items, binding index to 'idx' and item to 'item'
 //   - "(key, value) in items" - for map iteration
+//   - "item in filter(items, .active)" - iterates over the result of an expression
 //   - "_" can be used to omit a variable
 func parseForExpr(expr string) (*ForLoopExpr, error) {
 	expr = strings.TrimSpace(expr)
 
 	// Pattern 1: (var1, var2, ...) in source
-	// Source can be a simple name or a dotted path (e.g., item.subitem.array)
-	tuplePattern := regexp.MustCompile(`^\((.*?)\)\s+in\s+([\w.]+)$`)
-	if matches := tuplePattern.FindStringSubmatch(expr); matches != nil {
+	// Source can be a dotted path (e.g., item.subitem.array) or an expression
+	if matches := forTuplePattern.FindStringSubmatch(expr); matches != nil {
 		varsPart := strings.TrimSpace(matches[1])
 		source := strings.TrimSpace(matches[2])
 
@@ -43,9 +50,8 @@ func parseForExpr(expr string) (*ForLoopExpr, error) {
 	}
 
 	// Pattern 2: var in source (single variable)
-	// Source can be a simple name or a dotted path (e.g., item.subitem.array)
-	simplePattern := regexp.MustCompile(`^(\w+)\s+in\s+([\w.]+)$`)
-	if matches := simplePattern.FindStringSubmatch(expr); matches != nil {
+	// Source can be a dotted path (e.g., item.subitem.array) or an expression
+	if matches := forSimplePattern.FindStringSubmatch(expr); matches != nil {
 		varName := strings.TrimSpace(matches[1])
 		source := strings.TrimSpace(matches[2])
 
@@ -59,7 +65,7 @@ func parseForExpr(expr string) (*ForLoopExpr, error) {
 		}, nil
 	}
 
-	return nil, fmt.Errorf("invalid for expression syntax: %q (expected 'var in source' or '(var1, var2) in source', source can be a path like 'item.subitem')", expr)
+	return nil, fmt.Errorf("invalid for expression syntax: %q (expected 'var in source' or '(var1, var2) in source', source can be a path like 'item.subitem' or an expression)", expr)
 }
 
 // isValidVarName checks if a string is a valid variable name or "_".
@@ -126,3 +132,36 @@ func valuesEqual(a, b any) bool {
 		return fmt.Sprintf("%v", a) == fmt.Sprintf("%v", b)
 	}
 }
+
+// withoutKey returns a shallow copy of m without key.
+// Processing uses it to drop directives without mutating the input document.
+func withoutKey(m map[string]any, key string) map[string]any {
+	result := make(map[string]any, len(m))
+	for k, v := range m {
+		if k != key {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// copyValue returns a deep copy of maps and slices in v.
+// Other values are returned as is.
+func copyValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(val))
+		for k, item := range val {
+			result[k] = copyValue(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(val))
+		for i, item := range val {
+			result[i] = copyValue(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
@@ -10,7 +10,9 @@ import (
 )
 
 func interpolateStringHelper(s string, st *stack.Stack) string {
-	v, _ := interpolation.InterpolateString(st, s)
+	ev := interpolation.NewEvaluator()
+	ev.SetUndefined(interpolation.UndefinedKeep)
+	v, _ := ev.InterpolateStringWithContext(st, s, "")
 	return v
 }
 
@@ -53,8 +55,8 @@ func TestInterpolateString(t *testing.T) {
 func TestContainsInterpolation(t *testing.T) {
 	require.True(t, containsInterpolation("Hello ${name}"))
 	require.False(t, containsInterpolation("Hello name"))
-	require.False(t, containsInterpolation("${unclosed"))
-	require.False(t, containsInterpolation("${"))
+	require.True(t, containsInterpolation("${unclosed"))
+	require.True(t, containsInterpolation("$${"))
 }
 
 // TestParseForExpr tests the internal parseForExpr function.
@@ -170,6 +172,20 @@ func TestParseForExpr(t *testing.T) {
 			wantSource: "config.items",
 			wantErr:    false,
 		},
+		{
+			name:       "expression source",
+			input:      "item in filter(items, .active)",
+			wantVars:   []string{"item"},
+			wantSource: "filter(items, .active)",
+			wantErr:    false,
+		},
+		{
+			name:       "tuple with array literal source",
+			input:      `(idx, status) in ["active", "pending"]`,
+			wantVars:   []string{"idx", "status"},
+			wantSource: `["active", "pending"]`,
+			wantErr:    false,
+		},
 	}
 
 	for _, tt := range tests {